@@ -0,0 +1,66 @@
+package xerrors
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Adapt makes the cause of err available to the errors.Unwrap, errors.Is and
+// errors.As functions, if err exposes it using a method that is not
+// supported by the errors package. Errors with the Cause() error method,
+// used by the github.com/pkg/errors package, and the InnerError() error
+// method are supported. Causes are adapted recursively.
+//
+// If err already implements an Unwrap method, or it does not expose a cause,
+// it is returned unchanged. If err is nil, nil is returned.
+func Adapt(err error) error {
+	if err == nil {
+		return nil
+	}
+	switch err.(type) {
+	case interface{ Unwrap() error }, interface{ Unwrap() []error }:
+		return err
+	}
+	var cause error
+	switch e := err.(type) {
+	case interface{ Cause() error }:
+		cause = e.Cause()
+	case interface{ InnerError() error }:
+		cause = e.InnerError()
+	}
+	if cause == nil {
+		return err
+	}
+	return &adapted{err: err, cause: Adapt(cause)}
+}
+
+// adapted exposes the cause of an error using the Unwrap method.
+type adapted struct {
+	err   error
+	cause error
+}
+
+// Error implements the error interface.
+func (e *adapted) Error() string {
+	return e.err.Error()
+}
+
+// Format implements the fmt.Formatter interface. It supports the same verbs
+// as the Format method of errors created by the WithWrapper function.
+func (e *adapted) Format(s fmt.State, verb rune) {
+	type _adapted adapted
+	formatError(s, verb, e, (*_adapted)(e))
+}
+
+// Unwrap implements the Wrapper interface.
+func (e *adapted) Unwrap() error {
+	return e.cause
+}
+
+func (e *adapted) As(target interface{}) bool {
+	return errors.As(e.err, target)
+}
+
+func (e *adapted) Is(target error) bool {
+	return errors.Is(e.err, target)
+}
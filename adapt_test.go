@@ -0,0 +1,78 @@
+package xerrors
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+type causeErr struct {
+	msg   string
+	cause error
+}
+
+func (e *causeErr) Error() string {
+	return e.msg
+}
+
+func (e *causeErr) Cause() error {
+	return e.cause
+}
+
+type innerErr struct {
+	msg   string
+	inner error
+}
+
+func (e *innerErr) Error() string {
+	return e.msg
+}
+
+func (e *innerErr) InnerError() error {
+	return e.inner
+}
+
+func TestAdapt(t *testing.T) {
+	cause := &causeErr{msg: "cause: EOF", cause: io.EOF}
+	inner := &innerErr{msg: "inner: cause: EOF", inner: cause}
+	tests := []struct {
+		err       error
+		want      string
+		wantSame  bool
+		wantIs    []error
+		wantDepth int
+	}{
+		{err: io.EOF, want: "EOF", wantSame: true, wantIs: []error{io.EOF}},
+		{err: New(io.EOF), want: "EOF", wantSame: true, wantIs: []error{io.EOF}, wantDepth: 1},
+		{err: &causeErr{msg: "foo"}, want: "foo", wantSame: true},
+		{err: cause, want: "cause: EOF", wantIs: []error{cause, io.EOF}, wantDepth: 1},
+		{err: inner, want: "inner: cause: EOF", wantIs: []error{inner, cause, io.EOF}, wantDepth: 2},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			got := Adapt(tt.err)
+			if got.Error() != tt.want {
+				t.Errorf("Adapt(%#v): got: %q, want: %q", tt.err, got, tt.want)
+			}
+			if (got == tt.err) != tt.wantSame {
+				t.Errorf("Adapt(%#v): must return err unchanged: %v", tt.err, tt.wantSame)
+			}
+			for _, target := range tt.wantIs {
+				if !errors.Is(got, target) {
+					t.Errorf("errors.Is(Adapt(%#v), %#v): must return true", tt.err, target)
+				}
+			}
+			if d := Depth(got); d != tt.wantDepth {
+				t.Errorf("Depth(Adapt(%#v)): got: %d, want: %d", tt.err, d, tt.wantDepth)
+			}
+		})
+	}
+	if Adapt(nil) != nil {
+		t.Errorf("Adapt(nil): must return nil")
+	}
+	var ce *causeErr
+	if !errors.As(Adapt(inner), &ce) || ce != cause {
+		t.Errorf("errors.As(Adapt(inner), &ce): must find the cause")
+	}
+}
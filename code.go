@@ -0,0 +1,121 @@
+package xerrors
+
+import (
+	"fmt"
+)
+
+// WithCode attaches an error code to err. The code can be retrieved using
+// the Code function. It does not record a stack trace.
+//
+// If err is nil, then nil is returned.
+func WithCode(err error, code int) error {
+	if err == nil {
+		return nil
+	}
+	return &withCode{
+		err:  err,
+		code: code,
+	}
+}
+
+// Code returns the error code attached to err by the WithCode function. If
+// the error chain contains multiple codes, the outermost one is returned.
+//
+// If err does not have an error code, then (0, false) is returned.
+func Code(err error) (int, bool) {
+	for err != nil {
+		if e, ok := err.(*withCode); ok {
+			return e.code, true
+		}
+		if e, ok := err.(Wrapper); ok {
+			err = e.Unwrap()
+			continue
+		}
+		break
+	}
+	return 0, false
+}
+
+// withCode adds an error code to an error.
+type withCode struct {
+	err  error
+	code int
+}
+
+// Error implements the error interface.
+func (e *withCode) Error() string {
+	return e.err.Error()
+}
+
+// Format implements the fmt.Formatter interface. It supports the same verbs
+// as the Format method of errors created by the WithWrapper function.
+func (e *withCode) Format(s fmt.State, verb rune) {
+	type _withCode withCode
+	formatError(s, verb, e, (*_withCode)(e))
+}
+
+// Unwrap implements the Wrapper interface.
+func (e *withCode) Unwrap() error {
+	return e.err
+}
+
+// WithHTTPStatus attaches an HTTP status code to err. The status can be
+// retrieved using the HTTPStatus function. It does not record a stack trace.
+//
+// If err is nil, then nil is returned. If status is not in the 100-599
+// range, then err is returned unchanged.
+func WithHTTPStatus(err error, status int) error {
+	if err == nil {
+		return nil
+	}
+	if status < 100 || status > 599 {
+		return err
+	}
+	return &withHTTPStatus{
+		err:    err,
+		status: status,
+	}
+}
+
+// HTTPStatus returns the HTTP status code attached to err by the
+// WithHTTPStatus function. If the error chain contains multiple status
+// codes, the outermost one is returned, so it is possible to override
+// a status set by a lower layer.
+//
+// If err does not have an HTTP status code, then 500 is returned.
+func HTTPStatus(err error) int {
+	for err != nil {
+		if e, ok := err.(*withHTTPStatus); ok {
+			return e.status
+		}
+		if e, ok := err.(Wrapper); ok {
+			err = e.Unwrap()
+			continue
+		}
+		break
+	}
+	return 500 // http.StatusInternalServerError
+}
+
+// withHTTPStatus adds an HTTP status code to an error.
+type withHTTPStatus struct {
+	err    error
+	status int
+}
+
+// Error implements the error interface.
+func (e *withHTTPStatus) Error() string {
+	return e.err.Error()
+}
+
+// Format implements the fmt.Formatter interface. It supports the same verbs
+// as the Format method of errors created by the WithWrapper function.
+func (e *withHTTPStatus) Format(s fmt.State, verb rune) {
+	type _withHTTPStatus withHTTPStatus
+	formatError(s, verb, e, (*_withHTTPStatus)(e))
+}
+
+// Unwrap implements the Wrapper interface.
+func (e *withHTTPStatus) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,83 @@
+package xerrors
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestWithCode(t *testing.T) {
+	tests := []struct {
+		err      error
+		want     string
+		wantCode int
+		wantOk   bool
+		wantNil  bool
+	}{
+		{err: WithCode(nil, 42), wantNil: true},
+		{err: io.EOF, want: "EOF"},
+		{err: WithCode(io.EOF, 42), want: "EOF", wantCode: 42, wantOk: true},
+		{err: New("foo", WithCode(io.EOF, 42)), want: "foo: EOF", wantCode: 42, wantOk: true},
+		{err: WithCode(New(WithCode(io.EOF, 42)), 314), want: "EOF", wantCode: 314, wantOk: true},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			if tt.wantNil {
+				if tt.err != nil {
+					t.Errorf("WithCode(nil, code): must return nil")
+				}
+				return
+			}
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("WithCode(err, code).Error(): got: %q, want: %q", got, tt.want)
+			}
+			if len(StackTrace(WithCode(io.EOF, 42))) != 0 {
+				t.Errorf("WithCode(err, code): returned error must not contain a stack trace")
+			}
+			if !errors.Is(tt.err, io.EOF) {
+				t.Errorf("errors.Is(WithCode(err, code), err): must return true")
+			}
+			code, ok := Code(tt.err)
+			if code != tt.wantCode || ok != tt.wantOk {
+				t.Errorf("Code(%#v): got: (%d, %v), want: (%d, %v)", tt.err, code, ok, tt.wantCode, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestWithHTTPStatus(t *testing.T) {
+	tests := []struct {
+		err        error
+		want       string
+		wantStatus int
+		wantNil    bool
+	}{
+		{err: WithHTTPStatus(nil, 404), wantNil: true},
+		{err: io.EOF, want: "EOF", wantStatus: 500},
+		{err: WithHTTPStatus(io.EOF, 404), want: "EOF", wantStatus: 404},
+		{err: WithHTTPStatus(io.EOF, 99), want: "EOF", wantStatus: 500},
+		{err: WithHTTPStatus(io.EOF, 600), want: "EOF", wantStatus: 500},
+		{err: New("foo", WithHTTPStatus(io.EOF, 404)), want: "foo: EOF", wantStatus: 404},
+		{err: WithHTTPStatus(New(WithHTTPStatus(io.EOF, 404)), 403), want: "EOF", wantStatus: 403},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			if tt.wantNil {
+				if tt.err != nil {
+					t.Errorf("WithHTTPStatus(nil, status): must return nil")
+				}
+				return
+			}
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("WithHTTPStatus(err, status).Error(): got: %q, want: %q", got, tt.want)
+			}
+			if !errors.Is(tt.err, io.EOF) {
+				t.Errorf("errors.Is(WithHTTPStatus(err, status), err): must return true")
+			}
+			if got := HTTPStatus(tt.err); got != tt.wantStatus {
+				t.Errorf("HTTPStatus(%#v): got: %d, want: %d", tt.err, got, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package xerrors
+
+import (
+	"context"
+)
+
+type contextKey struct{}
+
+// ContextWithError returns a copy of ctx that carries err. If ctx already
+// carries an error, both errors are combined using the Append function.
+// It can be used to collect non-fatal errors during processing of a request.
+//
+// If err is nil, ctx is returned unchanged.
+func ContextWithError(ctx context.Context, err error) context.Context {
+	if err == nil {
+		return ctx
+	}
+	prev := ErrorFromContext(ctx)
+	if me, ok := prev.(multiError); ok {
+		// Copy the list, so that contexts derived from the same parent do
+		// not share the underlying array.
+		prev = multiError(me.Errors())
+	}
+	return context.WithValue(ctx, contextKey{}, Append(prev, err))
+}
+
+// ErrorFromContext returns the error stored in ctx by the ContextWithError
+// function. If there is no error, nil is returned.
+func ErrorFromContext(ctx context.Context) error {
+	err, _ := ctx.Value(contextKey{}).(error)
+	return err
+}
@@ -0,0 +1,37 @@
+package xerrors
+
+import (
+	"context"
+	"testing"
+)
+
+func TestContextWithError(t *testing.T) {
+	a, b, c := Message("a"), Message("b"), Message("c")
+	ctx := context.Background()
+	if err := ErrorFromContext(ctx); err != nil {
+		t.Errorf("ErrorFromContext(ctx): got: %q, want nil", err)
+	}
+	if got := ContextWithError(ctx, nil); got != ctx {
+		t.Errorf("ContextWithError(ctx, nil): must return ctx unchanged")
+	}
+	ctx = ContextWithError(ctx, a)
+	if err := ErrorFromContext(ctx); err != a {
+		t.Errorf("ErrorFromContext(ctx): got: %#v, want %#v", err, a)
+	}
+	ctx = ContextWithError(ctx, b)
+	ctxB := ContextWithError(ctx, b)
+	ctxC := ContextWithError(ctx, c)
+	tests := []struct {
+		ctx  context.Context
+		want string
+	}{
+		{ctx: ctx, want: "the following errors occurred: [a, b]"},
+		{ctx: ctxB, want: "the following errors occurred: [a, b, b]"},
+		{ctx: ctxC, want: "the following errors occurred: [a, b, c]"},
+	}
+	for _, tt := range tests {
+		if err := ErrorFromContext(tt.ctx); err == nil || err.Error() != tt.want {
+			t.Errorf("ErrorFromContext(ctx): got: %v, want %q", err, tt.want)
+		}
+	}
+}
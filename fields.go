@@ -0,0 +1,24 @@
+package xerrors
+
+// Fields returns additional information about err as a list of alternating
+// keys and values, which can be passed directly to structured loggers, for
+// example, slog.Logger.With.
+//
+// The following fields are returned if available:
+//
+// - code: the error code attached using the WithCode function
+//
+// - stack: the stack trace returned by the StackTrace function, formatted
+// as a string
+//
+// If err is nil or it does not contain any of these, nil is returned.
+func Fields(err error) []interface{} {
+	var fields []interface{}
+	if code, ok := Code(err); ok {
+		fields = append(fields, "code", code)
+	}
+	if st := StackTrace(err); len(st) > 0 {
+		fields = append(fields, "stack", st.String())
+	}
+	return fields
+}
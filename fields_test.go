@@ -0,0 +1,29 @@
+package xerrors
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestFields(t *testing.T) {
+	withStack := New("foo")
+	tests := []struct {
+		err  error
+		want []interface{}
+	}{
+		{err: nil, want: nil},
+		{err: io.EOF, want: nil},
+		{err: WithCode(io.EOF, 42), want: []interface{}{"code", 42}},
+		{err: withStack, want: []interface{}{"stack", StackTrace(withStack).String()}},
+		{err: WithCode(withStack, 42), want: []interface{}{"code", 42, "stack", StackTrace(withStack).String()}},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			if got := Fields(tt.err); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fields(%#v): got: %#v, want %#v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
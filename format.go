@@ -7,10 +7,116 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 var errWriter io.Writer = os.Stderr
 
+var formatter Formatter = defaultFormatter{}
+
+var compactStackTraces bool
+
+var includeStackTrace = true
+
+// SetIncludeStackTrace enables or disables printing of stack traces by the
+// Print, Sprint and Fprint functions. When disabled, errors with a stack
+// trace are printed without their details. Stack traces are printed by
+// default.
+//
+// This function is not thread-safe and should be called only once, during
+// the program initialization.
+func SetIncludeStackTrace(enabled bool) {
+	includeStackTrace = enabled
+}
+
+var (
+	indentString = "\t"
+	lineEnding   = "\n"
+)
+
+// SetIndent sets the string used to indent error details, such as stack
+// trace frames, and the continuation lines of errors in a list of errors.
+// The default indentation is a single tab.
+//
+// This function is not thread-safe and should be called only once, during
+// the program initialization.
+func SetIndent(s string) {
+	indentString = s
+}
+
+// SetLineEnding sets the string used to end lines of formatted errors and
+// stack traces. The default line ending is "\n". It can be set to "\r\n"
+// for tools that expect Windows line endings.
+//
+// Details returned by errors from other packages are printed unchanged.
+//
+// This function is not thread-safe and should be called only once, during
+// the program initialization.
+func SetLineEnding(s string) {
+	lineEnding = s
+}
+
+var colorOutput bool
+
+// SetColor enables or disables colored output of the Print and Fprint
+// functions. When enabled, error messages are printed in red and stack
+// traces are dimmed using ANSI escape codes. Colors are used only if the
+// writer is a terminal and the NO_COLOR environment variable is not set.
+// Custom formatters set by SetFormatter are not colored. Colors are
+// disabled by default.
+//
+// This function is not thread-safe and should be called only once, during
+// the program initialization.
+func SetColor(enabled bool) {
+	colorOutput = enabled
+}
+
+// Formatter renders errors printed by the Print, Sprint and Fprint functions.
+//
+// These functions traverse the error chain and call the FormatError method
+// for the first error in the chain and for every wrapped error that
+// implements the DetailedError interface. Consecutive errors with identical
+// messages, such as an error wrapped only to add a stack trace, are printed
+// once, with their details combined. Stack traces of such errors are
+// separated with a "previous stack trace:" line.
+type Formatter interface {
+	// FormatError writes err to w. The first argument reports whether err
+	// is the first error in the chain. The details argument contains the
+	// result of the ErrorDetails method, or an empty string if err does not
+	// implement the DetailedError interface.
+	FormatError(w io.Writer, err error, first bool, details string)
+}
+
+// SetFormatter sets the formatter used by the Print, Sprint and Fprint
+// functions. If f is nil, the default formatter is restored.
+//
+// The default formatter prints the first error with the "Error: " prefix,
+// and the following errors with the "Previous error: " prefix. Every error
+// is followed by its details.
+//
+// This function is not thread-safe and should be called only once, during
+// the program initialization.
+func SetFormatter(f Formatter) {
+	if f == nil {
+		f = defaultFormatter{}
+	}
+	formatter = f
+}
+
+// SetCompactStackTraces enables or disables the compact stack trace mode
+// used by the Print, Sprint and Fprint functions.
+//
+// When an error with a stack trace wraps another error with a stack trace,
+// both stack traces usually share most of their frames. In compact mode,
+// frames shared with the previously printed stack trace are omitted and
+// replaced with a line that contains the number of omitted frames.
+//
+// This function is not thread-safe and should be called only once, during
+// the program initialization.
+func SetCompactStackTraces(enabled bool) {
+	compactStackTraces = enabled
+}
+
 // Print formats an error and prints it on stderr.
 //
 // If the error implements the DetailedError interface, the result from the
@@ -18,7 +124,9 @@ var errWriter io.Writer = os.Stderr
 // Error method is used. A formatted error can be multi-line and always ends
 // with a newline.
 func Print(err error) {
-	fprint(errWriter, err)
+	p := newPrinter()
+	p.color = useColor(errWriter)
+	p.fprint(errWriter, err)
 }
 
 // Sprint formats an error and returns it as a string.
@@ -28,9 +136,15 @@ func Print(err error) {
 // Error method is used. A formatted error can be multi-line and always ends
 // with a newline.
 func Sprint(err error) string {
-	s := &strings.Builder{}
-	fprint(s, err)
-	return s.String()
+	return newPrinter().sprint(err)
+}
+
+// SprintWithoutStack works like Sprint, but it omits stack traces, regardless
+// of the SetIncludeStackTrace setting.
+func SprintWithoutStack(err error) string {
+	p := newPrinter()
+	p.stack = false
+	return p.sprint(err)
 }
 
 // Fprint formats an error and writes it to the given writer.
@@ -40,44 +154,349 @@ func Sprint(err error) string {
 // Error method is used. A formatted error can be multi-line and always ends
 // with a newline.
 func Fprint(w io.Writer, err error) (int, error) {
-	return fprint(w, err)
+	p := newPrinter()
+	p.color = useColor(w)
+	return p.fprint(w, err)
+}
+
+// SprintMarkdown formats an error as Markdown and returns it as a string.
+// It can be used, for example, to create the body of a bug report.
+//
+// Errors are printed in the same order as by the Sprint function. The
+// message of every error is printed as a heading, and its details, such as
+// the stack trace, are printed below the heading in a fenced code block.
+func SprintMarkdown(err error) string {
+	p := newPrinter()
+	p.formatter = markdownFormatter{}
+	return p.sprint(err)
+}
+
+// SprintTree formats an error as a tree and returns it as a string. Every
+// line contains the message of a single error, and the errors it wraps are
+// printed below it as its branches. Lists of errors are printed as multiple
+// branches. Errors that do not change the message of the error they wrap,
+// such as errors that only add a stack trace, are omitted.
+//
+// The result does not contain error details, such as stack traces. It always
+// ends with a newline, unless err is nil, in which case an empty string is
+// returned.
+func SprintTree(err error) string {
+	if err == nil {
+		return ""
+	}
+	s := &strings.Builder{}
+	s.WriteString(err.Error())
+	s.WriteString(lineEnding)
+	writeTree(s, err, "")
+	return s.String()
 }
 
-func fprint(w io.Writer, e error) (n int, err error) {
-	const firstErrorPrefix = "Error: "
-	const previousErrorPrefix = "Previous error: "
-	b := &bytes.Buffer{}
+// writeTree writes the branches of the err node to w. Each line is prefixed
+// with prefix.
+func writeTree(w io.Writer, err error, prefix string) {
+	errs := treeBranches(err)
+	for n, e := range errs {
+		branch, next := "├─ ", "│  "
+		if n == len(errs)-1 {
+			branch, next = "└─ ", "   "
+		}
+		io.WriteString(w, prefix)
+		io.WriteString(w, branch)
+		io.WriteString(w, e.Error())
+		io.WriteString(w, lineEnding)
+		writeTree(w, e, prefix+next)
+	}
+}
+
+// treeBranches returns the errors wrapped by err, skipping wrapped errors
+// with the same message as err.
+func treeBranches(err error) []error {
+	msg := fullMessage(err)
+	for {
+		switch e := err.(type) {
+		case MultiError:
+			return e.Errors()
+		case interface{ Unwrap() []error }:
+			return e.Unwrap()
+		case Wrapper:
+			u := e.Unwrap()
+			if u == nil {
+				return nil
+			}
+			if fullMessage(u) == msg {
+				err = u
+				continue
+			}
+			return []error{u}
+		}
+		return nil
+	}
+}
+
+// printer prints errors using a Formatter, by default the one set by the
+// SetFormatter function.
+type printer struct {
+	formatter Formatter
+	stack     bool // include stack traces
+	compact   bool // omit stack trace frames shared with the previous trace
+	color     bool // use ANSI colors
+}
+
+// newPrinter returns a printer configured with the package settings.
+func newPrinter() printer {
+	return printer{
+		formatter: formatter,
+		stack:     includeStackTrace,
+		compact:   compactStackTraces,
+	}
+}
+
+// bufferPool holds buffers used to format errors.
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return &bytes.Buffer{}
+	},
+}
+
+// maxPooledBufferSize is the capacity above which buffers are not returned
+// to the pool, to avoid retaining memory after formatting a huge error.
+const maxPooledBufferSize = 64 << 10
+
+func getBuffer() *bytes.Buffer {
+	b := bufferPool.Get().(*bytes.Buffer)
+	b.Reset()
+	return b
+}
+
+func putBuffer(b *bytes.Buffer) {
+	if b.Cap() <= maxPooledBufferSize {
+		bufferPool.Put(b)
+	}
+}
+
+func (p printer) sprint(e error) string {
+	b := getBuffer()
+	defer putBuffer(b)
+	p.write(b, e)
+	return b.String()
+}
+
+func (p printer) fprint(w io.Writer, e error) (n int, err error) {
+	b := getBuffer()
+	defer putBuffer(b)
+	p.write(b, e)
+	return w.Write(b.Bytes())
+}
+
+func (p printer) write(b *bytes.Buffer, e error) {
+	// Colors are used only with the default formatter, because a custom
+	// formatter may not expect escape sequences in the details.
+	fm := p.formatter
+	color := false
+	if _, ok := fm.(defaultFormatter); ok && p.color {
+		fm = defaultFormatter{color: true}
+		color = true
+	}
 	f := true
+	var st Callers
+	// Consecutive errors with identical messages are printed once, so the
+	// error waiting to be printed is kept until an error with a different
+	// message is found. The details of the skipped errors are combined, and
+	// stack traces are separated with a line, so they are not printed as
+	// a single trace. Submitter stack traces are printed after the other
+	// details.
+	var (
+		pending         error
+		pendingFirst    bool
+		pendingMsg      string
+		pendingDetails  string
+		pendingTrailing string
+		pendingStack    bool // pendingDetails contains a stack trace
+		prev            error
+		prevPending     bool // prev has the same message as pending
+	)
+	flush := func() {
+		if pending != nil {
+			fm.FormatError(b, pending, pendingFirst, pendingDetails+pendingTrailing)
+			pending = nil
+		}
+	}
 	for e != nil {
+		// Messages are compared without truncation, so that distinct
+		// errors with the same truncated message are not combined. They
+		// are built only for errors that are printed, because building
+		// the message of every error in a deep chain is expensive.
+		cur := false
 		switch terr := e.(type) {
 		case DetailedError:
-			if f {
-				b.WriteString(firstErrorPrefix)
-			} else {
-				b.WriteString(previousErrorPrefix)
+			msg := fullMessage(e)
+			var details, trailing string
+			stack := false
+			switch de := terr.(type) {
+			case *withSubmitterStack:
+				if p.stack {
+					trailing = de.ErrorDetails()
+					if color {
+						trailing = ansiDim + trailing + ansiReset
+					}
+				}
+			case *withStackTrace:
+				if p.stack {
+					s := &strings.Builder{}
+					if p.compact {
+						de.writeDetails(s, st)
+					} else {
+						de.writeDetails(s, nil)
+					}
+					details = s.String()
+					stack = len(details) > 0
+					if color && len(details) > 0 {
+						details = ansiDim + details + ansiReset
+					}
+				}
+			case multiError:
+				details = de.errorDetails(p)
+			default:
+				details = terr.ErrorDetails()
 			}
-			b.WriteString(terr.Error())
-			b.WriteByte('\n')
-			b.WriteString(terr.ErrorDetails())
+			cur = true
+			if pending != nil && msg == pendingMsg && (prevPending || fullMessage(prev) == msg) {
+				if stack && pendingStack {
+					pendingDetails += indentString + "previous stack trace:" + lineEnding
+				}
+				pendingDetails += details
+				pendingTrailing += trailing
+				pendingStack = pendingStack || stack
+				break
+			}
+			flush()
+			pending, pendingFirst, pendingMsg = terr, f, msg
+			pendingDetails, pendingTrailing = withFullMessage(e, msg, details), trailing
+			pendingStack = stack
 		default:
 			// If an error does not implement the DetailedError interface,
 			// then the Error() method will print all errors separated
 			// with ":", so there is no need to render each error other than
 			// the first one.
 			if f {
-				b.WriteString(firstErrorPrefix)
-				b.WriteString(terr.Error())
-				b.WriteByte('\n')
+				msg := fullMessage(e)
+				pending, pendingFirst, pendingMsg = terr, f, msg
+				pendingDetails, pendingTrailing = withFullMessage(e, msg, ""), ""
+				pendingStack = false
+				cur = true
 			}
 		}
 		f = false
+		prev, prevPending = e, cur
+		if se, ok := e.(StackTracer); ok {
+			st = se.StackTrace()
+		}
 		if we, ok := e.(Wrapper); ok {
 			e = we.Unwrap()
 			continue
 		}
 		break
 	}
-	return w.Write(b.Bytes())
+	flush()
+}
+
+// withFullMessage prepends full, the full message of err, to details, if
+// the result of the Error method was truncated.
+func withFullMessage(err error, full, details string) string {
+	if err.Error() != full {
+		return indentString + "full message: " + full + lineEnding + details
+	}
+	return details
+}
+
+// defaultFormatter is the Formatter used by default.
+type defaultFormatter struct {
+	color bool // print the error message in red
+}
+
+// FormatError implements the Formatter interface.
+func (f defaultFormatter) FormatError(w io.Writer, err error, first bool, details string) {
+	if f.color {
+		io.WriteString(w, ansiRed)
+	}
+	if first {
+		io.WriteString(w, "Error: ")
+	} else {
+		io.WriteString(w, "Previous error: ")
+	}
+	io.WriteString(w, err.Error())
+	if f.color {
+		io.WriteString(w, ansiReset)
+	}
+	io.WriteString(w, lineEnding)
+	io.WriteString(w, details)
+}
+
+// markdownFormatter is the Formatter used by the SprintMarkdown function.
+type markdownFormatter struct{}
+
+// FormatError implements the Formatter interface.
+func (markdownFormatter) FormatError(w io.Writer, err error, first bool, details string) {
+	if first {
+		io.WriteString(w, "## Error: ")
+	} else {
+		io.WriteString(w, lineEnding)
+		io.WriteString(w, "## Previous error: ")
+	}
+	io.WriteString(w, err.Error())
+	io.WriteString(w, lineEnding)
+	if details == "" {
+		return
+	}
+	io.WriteString(w, lineEnding)
+	io.WriteString(w, "```")
+	io.WriteString(w, lineEnding)
+	io.WriteString(w, details)
+	io.WriteString(w, "```")
+	io.WriteString(w, lineEnding)
+}
+
+const (
+	ansiRed   = "\x1b[31m"
+	ansiDim   = "\x1b[2m"
+	ansiReset = "\x1b[0m"
+)
+
+// useColor reports whether colors should be used when printing errors to w.
+func useColor(w io.Writer) bool {
+	if !colorOutput || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	f, ok := w.(interface{ Stat() (os.FileInfo, error) })
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// formatError writes err to s according to the verb. The %s and %v verbs
+// print the error message, %+v prints the same output as the Sprint function
+// and %q prints the quoted error message. For the %#v verb and unsupported
+// verbs, v is printed using the format function.
+func formatError(s fmt.State, verb rune, err error, v interface{}) {
+	switch verb {
+	case 's':
+		io.WriteString(s, err.Error())
+	case 'v':
+		switch {
+		case s.Flag('+'):
+			io.WriteString(s, Sprint(err))
+		case s.Flag('#'):
+			format(s, verb, v)
+		default:
+			io.WriteString(s, err.Error())
+		}
+	case 'q':
+		io.WriteString(s, strconv.Quote(err.Error()))
+	default:
+		format(s, verb, v)
+	}
 }
 
 func format(s fmt.State, verb rune, v interface{}) {
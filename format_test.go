@@ -2,6 +2,9 @@ package xerrors
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"regexp"
 	"strings"
 	"testing"
 )
@@ -47,6 +50,18 @@ func TestFormat(t *testing.T) {
 			err:  testErr{err: "err", details: "details", wrapped: testErr{err: "wrapped err", details: "wrapped details"}},
 			want: "Error: err\ndetails\nPrevious error: wrapped err\nwrapped details\n",
 		},
+		{
+			err:  fmt.Errorf("ctx: %w", testErr{err: "err", details: "details"}),
+			want: "Error: ctx: err\nPrevious error: err\ndetails\n",
+		},
+		{
+			err:  testErr{err: "foo", details: "d1", wrapped: testErr{err: "foo", details: "d2", wrapped: testErr{err: "foo bar", details: "d3"}}},
+			want: "Error: foo\nd1\nd2\nPrevious error: foo bar\nd3\n",
+		},
+		{
+			err:  testErr{err: "foo", details: "d1", wrapped: testErr{err: "bar", details: "d2", wrapped: testErr{err: "foo", details: "d3"}}},
+			want: "Error: foo\nd1\nPrevious error: bar\nd2\nPrevious error: foo\nd3\n",
+		},
 	}
 	for n, tt := range tests {
 		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
@@ -57,6 +72,55 @@ func TestFormat(t *testing.T) {
 	}
 }
 
+func TestFormatVerbs(t *testing.T) {
+	tests := []struct {
+		format string
+		err    error
+		want   string
+	}{
+		{format: "%s", err: New("foo"), want: "foo"},
+		{format: "%v", err: New("foo"), want: "foo"},
+		{format: "%q", err: New("foo"), want: `"foo"`},
+		{format: "%s", err: WithWrapper(Message("foo"), Message("bar")), want: "foo: bar"},
+		{format: "%v", err: WithWrapper(Message("foo"), Message("bar")), want: "foo: bar"},
+		{format: "%q", err: WithWrapper(Message("foo"), Message("bar")), want: `"foo: bar"`},
+		{format: "%+v", err: WithWrapper(Message("foo"), Message("bar")), want: "Error: foo: bar\n"},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			if got := fmt.Sprintf(tt.format, tt.err); got != tt.want {
+				t.Errorf("fmt.Sprintf(%q, %q): got: %q, want: %q", tt.format, tt.err, got, tt.want)
+			}
+		})
+	}
+	err := New("foo")
+	if got, want := fmt.Sprintf("%+v", err), Sprint(err); got != want {
+		t.Errorf("fmt.Sprintf(\"%%+v\", err): got: %q, want: %q", got, want)
+	}
+	if got := fmt.Sprintf("%#v", err); !strings.HasPrefix(got, "&xerrors._withStackTrace{") {
+		t.Errorf("fmt.Sprintf(\"%%#v\", err): got: %q, want the struct details", got)
+	}
+	annotated := []error{
+		WithCode(err, 1),
+		WithHTTPStatus(err, 404),
+		WithTimestamp(err),
+		WithSeverity(err, SeverityWarn),
+		WithPublicMessage(err, "bar"),
+		WithRetryable(err),
+		WithSubmitterStack(err, CaptureHere()),
+		WithHiddenCause(Message("bar"), err),
+		Adapt(&causeErr{msg: "bar", cause: err}),
+	}
+	for n, err := range annotated {
+		if got, want := fmt.Sprintf("%+v", err), Sprint(err); got != want {
+			t.Errorf("case-%d: fmt.Sprintf(\"%%+v\", err): got: %q, want: %q", n+1, got, want)
+		}
+		if got, want := fmt.Sprintf("%s", err), err.Error(); got != want {
+			t.Errorf("case-%d: fmt.Sprintf(\"%%s\", err): got: %q, want: %q", n+1, got, want)
+		}
+	}
+}
+
 func TestPrint(t *testing.T) {
 	prevErrWriter := errWriter
 	defer func() { errWriter = prevErrWriter }()
@@ -100,3 +164,232 @@ func TestFprint(t *testing.T) {
 		t.Errorf("Fprint(buf, %#v): wrote invalid error message, got %q but %q expected", err, got, exp)
 	}
 }
+
+func TestSetCompactStackTraces(t *testing.T) {
+	defer SetCompactStackTraces(false)
+
+	inner := func() error { return New("foo") }
+	cause := inner()
+	err := New("bar", cause)
+	full := Sprint(err)
+	SetCompactStackTraces(true)
+	got := Sprint(err)
+	want := `^Error: bar: foo\n\tat go-xerrors.TestSetCompactStackTraces \(.*\)\n(\tat .*\n)+` +
+		`Previous error: foo\n\tat go-xerrors.TestSetCompactStackTraces.func1 \(.*\)\n\tat go-xerrors.TestSetCompactStackTraces \(.*\)\n` +
+		`\t\.\.\. [0-9]+ frames identical to above\n$`
+	if match, _ := regexp.MatchString(want, got); !match {
+		t.Errorf("Sprint(err): %q does not match %q", got, want)
+	}
+	if len(got) >= len(full) {
+		t.Errorf("Sprint(err): compact output must be shorter than %q", full)
+	}
+}
+
+func TestSprintIdenticalMessages(t *testing.T) {
+	inner := func() error { return New("foo") }
+	got := Sprint(New(inner()))
+	want := `^Error: foo\n\tat go-xerrors.TestSprintIdenticalMessages \(.*\)\n(\tat .*\n)+` +
+		`\tprevious stack trace:\n\tat go-xerrors.TestSprintIdenticalMessages.func1 \(.*\)\n(\tat .*\n)+$`
+	if match, _ := regexp.MatchString(want, got); !match {
+		t.Errorf("Sprint(New(New(\"foo\"))): %q does not match %q", got, want)
+	}
+	got = Sprint(WithTimestamp(New("foo")))
+	if strings.Contains(got, "previous stack trace:") {
+		t.Errorf("Sprint(WithTimestamp(New(\"foo\"))): %q must not contain a separator", got)
+	}
+}
+
+func TestSetCompactStackTracesTrimBelow(t *testing.T) {
+	defer SetCompactStackTraces(false)
+	defer SetTrimBelow("")
+
+	SetCompactStackTraces(true)
+	SetTrimBelow("testing.tRunner")
+	inner := func() error { return New("foo") }
+	cause := inner()
+	got := Sprint(New("bar", cause))
+	want := `^Error: bar: foo\n\tat go-xerrors.TestSetCompactStackTracesTrimBelow \(.*\)\n\tat testing.tRunner \(.*\)\n` +
+		`Previous error: foo\n\tat go-xerrors.TestSetCompactStackTracesTrimBelow.func1 \(.*\)\n\tat go-xerrors.TestSetCompactStackTracesTrimBelow \(.*\)\n` +
+		`\t\.\.\. 1 frames identical to above\n$`
+	if match, _ := regexp.MatchString(want, got); !match {
+		t.Errorf("Sprint(err): %q does not match %q", got, want)
+	}
+}
+
+type testFormatter struct{}
+
+func (testFormatter) FormatError(w io.Writer, err error, first bool, details string) {
+	if !first {
+		io.WriteString(w, "caused by: ")
+	}
+	io.WriteString(w, err.Error())
+	io.WriteString(w, "\n")
+}
+
+func TestSetFormatter(t *testing.T) {
+	defer SetFormatter(nil)
+
+	err := testErr{err: "err", details: "details", wrapped: testErr{err: "wrapped err", details: "wrapped details"}}
+	SetFormatter(testFormatter{})
+	if got, want := Sprint(err), "err\ncaused by: wrapped err\n"; got != want {
+		t.Errorf("Sprint(%#v): got: %q, want %q", err, got, want)
+	}
+	SetFormatter(nil)
+	if got, want := Sprint(err), "Error: err\ndetails\nPrevious error: wrapped err\nwrapped details\n"; got != want {
+		t.Errorf("Sprint(%#v): got: %q, want %q", err, got, want)
+	}
+}
+
+func TestSprintWithoutStack(t *testing.T) {
+	defer SetIncludeStackTrace(true)
+
+	err := Append(New("foo"), New("bar", New("baz")))
+	want := "Error: the following errors occurred: [foo, bar: baz]\n1. Error: foo\n2. Error: bar: baz\n\tPrevious error: baz\n"
+	if got := SprintWithoutStack(err); got != want {
+		t.Errorf("SprintWithoutStack(%#v): got: %q, want %q", err, got, want)
+	}
+	if got := Sprint(err); !strings.Contains(got, "\tat ") {
+		t.Errorf("Sprint(%#v): %q must contain stack traces", err, got)
+	}
+	SetIncludeStackTrace(false)
+	if got := Sprint(err); got != want {
+		t.Errorf("Sprint(%#v): got: %q, want %q", err, got, want)
+	}
+}
+
+type testTerminal struct {
+	strings.Builder
+	mode os.FileMode
+}
+
+func (t *testTerminal) Stat() (os.FileInfo, error) {
+	return testFileInfo{mode: t.mode}, nil
+}
+
+type testFileInfo struct {
+	os.FileInfo
+	mode os.FileMode
+}
+
+func (fi testFileInfo) Mode() os.FileMode {
+	return fi.mode
+}
+
+func TestSetColor(t *testing.T) {
+	defer SetColor(false)
+
+	noColor, noColorSet := os.LookupEnv("NO_COLOR")
+	os.Unsetenv("NO_COLOR")
+	defer func() {
+		if noColorSet {
+			os.Setenv("NO_COLOR", noColor)
+		}
+	}()
+
+	err := New("foo")
+	tests := []struct {
+		color   bool
+		mode    os.FileMode
+		noColor string
+		want    string
+	}{
+		{color: false, mode: os.ModeCharDevice, want: "^Error: foo\n\tat "},
+		{color: true, mode: 0, want: "^Error: foo\n\tat "},
+		{color: true, mode: os.ModeCharDevice, noColor: "1", want: "^Error: foo\n\tat "},
+		{color: true, mode: os.ModeCharDevice, want: "^\x1b\\[31mError: foo\x1b\\[0m\n\x1b\\[2m\tat (.*\n)+\x1b\\[0m$"},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			SetColor(tt.color)
+			os.Setenv("NO_COLOR", tt.noColor)
+			defer os.Unsetenv("NO_COLOR")
+			w := &testTerminal{mode: tt.mode}
+			Fprint(w, err)
+			if match, _ := regexp.MatchString(tt.want, w.String()); !match {
+				t.Errorf("Fprint(w, %#v): %q does not match %q", err, w.String(), tt.want)
+			}
+		})
+	}
+	SetColor(true)
+	if got := Sprint(err); strings.Contains(got, "\x1b") {
+		t.Errorf("Sprint(%#v): %q must not contain colors", err, got)
+	}
+	SetFormatter(testDetailsFormatter{})
+	defer SetFormatter(nil)
+	w := &testTerminal{mode: os.ModeCharDevice}
+	Fprint(w, WithSubmitterStack(err, StackTrace(err)))
+	if got := w.String(); !strings.Contains(got, "\tat ") || strings.Contains(got, "\x1b") {
+		t.Errorf("Fprint(w, %#v): %q must contain details without colors", err, got)
+	}
+}
+
+type testDetailsFormatter struct{}
+
+func (testDetailsFormatter) FormatError(w io.Writer, err error, first bool, details string) {
+	io.WriteString(w, err.Error())
+	io.WriteString(w, "\n")
+	io.WriteString(w, details)
+}
+
+func TestSprintTree(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{err: nil, want: ""},
+		{err: Message("foo"), want: "foo\n"},
+		{err: New("foo"), want: "foo\n"},
+		{err: New("foo", New("bar")), want: "foo: bar\n└─ bar\n"},
+		{
+			err:  Append(New("a"), New("b", Append(Message("c"), Message("d"))), Message("e")),
+			want: "the following errors occurred: [a, b: the following errors occurred: [c, d], e]\n├─ a\n├─ b: the following errors occurred: [c, d]\n│  └─ the following errors occurred: [c, d]\n│     ├─ c\n│     └─ d\n└─ e\n",
+		},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			if got := SprintTree(tt.err); got != tt.want {
+				t.Errorf("SprintTree(%#v): got: %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func BenchmarkSprint(b *testing.B) {
+	err := New("foo", Append(New("bar"), New("baz")))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = Sprint(err)
+	}
+}
+
+func TestSetIndentSetLineEnding(t *testing.T) {
+	defer SetIndent("\t")
+	defer SetLineEnding("\n")
+
+	SetIndent("  ")
+	SetLineEnding("\r\n")
+	err := Append(Message("a"), New("b"))
+	got := Sprint(err)
+	want := `^Error: the following errors occurred: \[a, b\]\r\n1\. Error: a\r\n2\. Error: b\r\n(    at .*\r\n)+$`
+	if match, _ := regexp.MatchString(want, got); !match {
+		t.Errorf("Sprint(err): %q does not match %q", got, want)
+	}
+	if got, want := SprintTree(WithWrapper(Message("a"), Message("b"))), "a: b\r\n└─ b\r\n"; got != want {
+		t.Errorf("SprintTree(err): got: %q, want: %q", got, want)
+	}
+}
+
+func TestSprintMarkdown(t *testing.T) {
+	err := testErr{err: "err", details: "details", wrapped: testErr{err: "wrapped err", details: "wrapped details"}}
+	want := "## Error: err\n\n```\ndetails\n```\n\n## Previous error: wrapped err\n\n```\nwrapped details\n```\n"
+	if got := SprintMarkdown(err); got != want {
+		t.Errorf("SprintMarkdown(%#v): got: %q, want: %q", err, got, want)
+	}
+	if got, want := SprintMarkdown(Message("foo")), "## Error: foo\n"; got != want {
+		t.Errorf("SprintMarkdown(Message(\"foo\")): got: %q, want: %q", got, want)
+	}
+	want = "^## Error: foo\\n\\n```\\n(\\tat .*\\n)+```\\n$"
+	if got := SprintMarkdown(New("foo")); !regexp.MustCompile(want).MatchString(got) {
+		t.Errorf("SprintMarkdown(New(\"foo\")): %q does not match %q", got, want)
+	}
+}
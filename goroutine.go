@@ -0,0 +1,66 @@
+package xerrors
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+)
+
+var captureGoroutineID bool
+
+// SetCaptureGoroutineID enables or disables recording of the goroutine ID
+// along with stack traces. The recorded ID can be retrieved using the
+// GoroutineID function, and it is included in the error details.
+//
+// Obtaining the goroutine ID requires parsing the output of runtime.Stack,
+// so it is relatively expensive and intended only for debugging. It is
+// disabled by default.
+//
+// This function is not thread-safe and should be called only once, during
+// the program initialization.
+func SetCaptureGoroutineID(enabled bool) {
+	captureGoroutineID = enabled
+}
+
+// GoroutineID returns the ID of the goroutine in which the stack trace
+// returned by the StackTrace function was recorded.
+//
+// If the error does not have a stack trace, or the goroutine ID was not
+// recorded, then (0, false) is returned.
+func GoroutineID(err error) (uint64, bool) {
+	for err != nil {
+		if e, ok := err.(StackTracer); ok {
+			if e, ok := e.(*withStackTrace); ok && e.goid != 0 {
+				return e.goid, true
+			}
+			return 0, false
+		}
+		if e, ok := err.(Wrapper); ok {
+			err = e.Unwrap()
+			continue
+		}
+		break
+	}
+	return 0, false
+}
+
+// goroutineID returns the ID of the current goroutine, or 0 if recording
+// of goroutine IDs is disabled.
+func goroutineID() uint64 {
+	if !captureGoroutineID {
+		return 0
+	}
+	// The first line of the runtime.Stack output has the following
+	// format: "goroutine 18 [running]:".
+	var b [64]byte
+	s := b[:runtime.Stack(b[:], false)]
+	s = bytes.TrimPrefix(s, []byte("goroutine "))
+	if i := bytes.IndexByte(s, ' '); i >= 0 {
+		s = s[:i]
+	}
+	id, err := strconv.ParseUint(string(s), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
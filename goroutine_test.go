@@ -0,0 +1,35 @@
+package xerrors
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestGoroutineID(t *testing.T) {
+	defer SetCaptureGoroutineID(false)
+
+	if _, ok := GoroutineID(New("foo")); ok {
+		t.Errorf("GoroutineID(New(\"foo\")): goroutine ID must not be recorded by default")
+	}
+	if _, ok := GoroutineID(Message("foo")); ok {
+		t.Errorf("GoroutineID(Message(\"foo\")): must return false for errors without a stack trace")
+	}
+
+	SetCaptureGoroutineID(true)
+	ch := make(chan error)
+	for i := 0; i < 2; i++ {
+		go func() { ch <- New("foo") }()
+	}
+	err1, err2 := <-ch, <-ch
+	id1, ok1 := GoroutineID(err1)
+	id2, ok2 := GoroutineID(WithWrapper(Message("bar"), err2))
+	if !ok1 || !ok2 || id1 == 0 || id2 == 0 {
+		t.Fatalf("GoroutineID(err): goroutine ID must be recorded")
+	}
+	if id1 == id2 {
+		t.Errorf("GoroutineID(err): errors created in different goroutines must have different IDs")
+	}
+	if match, _ := regexp.MatchString(`^Error: foo\n\tin goroutine [0-9]+\n\tat `, Sprint(err1)); !match {
+		t.Errorf("Sprint(err): %q must contain goroutine ID", Sprint(err1))
+	}
+}
@@ -0,0 +1,48 @@
+package xerrors
+
+import (
+	"sync"
+)
+
+// ErrorGroup runs functions in separate goroutines and collects the errors
+// they return. Panics are recovered and converted to errors with a stack
+// trace, in the same way as in the Recover function.
+//
+// The zero value is ready to use. An ErrorGroup must not be copied after
+// first use.
+type ErrorGroup struct {
+	wg  sync.WaitGroup
+	mu  sync.Mutex
+	err error
+}
+
+// Go runs fn in a new goroutine.
+func (g *ErrorGroup) Go(fn func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		defer Recover(g.add)
+		g.add(fn())
+	}()
+}
+
+// Wait waits for all goroutines started with the Go method to finish and
+// returns the collected errors combined using the Append function. The order
+// of the errors is undefined.
+//
+// If no errors occurred, nil is returned.
+func (g *ErrorGroup) Wait() error {
+	g.wg.Wait()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.err
+}
+
+func (g *ErrorGroup) add(err error) {
+	if err == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.err = Append(g.err, err)
+}
@@ -0,0 +1,54 @@
+package xerrors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorGroup(t *testing.T) {
+	foo, bar := Message("foo"), Message("bar")
+	tests := []struct {
+		fns       []func() error
+		wantLen   int
+		wantErrs  []error
+		wantPanic bool
+	}{
+		{fns: nil, wantLen: 0},
+		{fns: []func() error{func() error { return nil }}, wantLen: 0},
+		{fns: []func() error{func() error { return foo }}, wantLen: 1, wantErrs: []error{foo}},
+		{fns: []func() error{func() error { return foo }, func() error { return bar }, func() error { return nil }}, wantLen: 2, wantErrs: []error{foo, bar}},
+		{fns: []func() error{func() error { return foo }, func() error { panic("baz") }}, wantLen: 2, wantErrs: []error{foo}, wantPanic: true},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			g := &ErrorGroup{}
+			for _, fn := range tt.fns {
+				g.Go(fn)
+			}
+			err := g.Wait()
+			if tt.wantLen == 0 {
+				if err != nil {
+					t.Errorf("ErrorGroup.Wait(): got: %q, want nil", err)
+				}
+				return
+			}
+			gotLen := 1
+			if me, ok := err.(MultiError); ok {
+				gotLen = len(me.Errors())
+			}
+			if gotLen != tt.wantLen {
+				t.Errorf("ErrorGroup.Wait(): got %d errors, want %d", gotLen, tt.wantLen)
+			}
+			for _, e := range tt.wantErrs {
+				if !errors.Is(err, e) {
+					t.Errorf("errors.Is(ErrorGroup.Wait(), %#v): must return true", e)
+				}
+			}
+			panicErr := &panicError{}
+			if errors.As(err, &panicErr) != tt.wantPanic {
+				t.Errorf("errors.As(ErrorGroup.Wait(), &panicErr): must return %v", tt.wantPanic)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+//go:build go1.23
+// +build go1.23
+
+package xerrors
+
+import (
+	"iter"
+)
+
+// Chain returns an iterator over the error chain of err. It yields err
+// first, followed by every error that can be obtained from it by repeatedly
+// calling the Unwrap method. If err is nil, nothing is yielded.
+func Chain(err error) iter.Seq[error] {
+	return func(yield func(error) bool) {
+		for err != nil {
+			if !yield(err) {
+				return
+			}
+			e, ok := err.(Wrapper)
+			if !ok {
+				return
+			}
+			err = e.Unwrap()
+		}
+	}
+}
+
+// Members returns an iterator over the errors in a list of errors created by
+// the Append function. If err is not a list, only err is yielded. If err is
+// nil, nothing is yielded. Nested lists are yielded as single errors.
+func Members(err error) iter.Seq[error] {
+	return func(yield func(error) bool) {
+		if err == nil {
+			return
+		}
+		me, ok := err.(multiError)
+		if !ok {
+			yield(err)
+			return
+		}
+		for _, e := range me {
+			if !yield(e) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+//go:build go1.23
+// +build go1.23
+
+package xerrors
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestChain(t *testing.T) {
+	a, b := Message("a"), Message("b")
+	ab := WithWrapper(a, b)
+	st := New(ab)
+	tests := []struct {
+		err  error
+		want []error
+	}{
+		{err: nil, want: nil},
+		{err: a, want: []error{a}},
+		{err: ab, want: []error{ab, b}},
+		{err: st, want: []error{st, ab, b}},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			var got []error
+			for e := range Chain(tt.err) {
+				got = append(got, e)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Chain(%#v): got: %#v, want: %#v", tt.err, got, tt.want)
+			}
+		})
+	}
+	for range Chain(st) {
+		break
+	}
+}
+
+func TestMembers(t *testing.T) {
+	a, b, c := Message("a"), Message("b"), Message("c")
+	nested := Append(b, c)
+	tests := []struct {
+		err  error
+		want []error
+	}{
+		{err: nil, want: nil},
+		{err: a, want: []error{a}},
+		{err: Append(a, b), want: []error{a, b}},
+		{err: multiError{a, nested}, want: []error{a, nested}},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			var got []error
+			for e := range Members(tt.err) {
+				got = append(got, e)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Members(%#v): got: %#v, want: %#v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
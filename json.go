@@ -0,0 +1,84 @@
+package xerrors
+
+import (
+	"encoding/json"
+)
+
+// MarshalJSON returns the JSON encoding of err.
+//
+// The error is encoded as an object with the following fields:
+//
+// - message: the result of the Error method
+//
+// - causes: errors used as wrappers and the innermost wrapped error,
+// encoded recursively, omitted if empty
+//
+// - errors: errors contained in a multierror, omitted if empty
+//
+// - stack: the first stack trace found in the error chain, as a list of
+// objects with the file, line and function fields, omitted if empty
+//
+// If err is nil, the result is "null".
+func MarshalJSON(err error) ([]byte, error) {
+	if err == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(newJSONError(err))
+}
+
+type jsonError struct {
+	Message string       `json:"message"`
+	Causes  []*jsonError `json:"causes,omitempty"`
+	Errors  []*jsonError `json:"errors,omitempty"`
+	Stack   []jsonFrame  `json:"stack,omitempty"`
+}
+
+type jsonFrame struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Function string `json:"function"`
+}
+
+func newJSONError(err error) *jsonError {
+	j := &jsonError{Message: err.Error()}
+	n := 0
+	for {
+		if e, ok := err.(StackTracer); ok && j.Stack == nil {
+			j.Stack = newJSONFrames(e.StackTrace())
+		}
+		if e, ok := err.(*withWrapper); ok {
+			j.Causes = append(j.Causes, newJSONError(e.wrapper))
+			err = e.err
+			n++
+			continue
+		}
+		if e, ok := err.(Wrapper); ok {
+			if u := e.Unwrap(); u != nil {
+				err = u
+				n++
+				continue
+			}
+		}
+		break
+	}
+	if n > 0 {
+		j.Causes = append(j.Causes, newJSONError(err))
+	} else if e, ok := err.(MultiError); ok {
+		for _, me := range e.Errors() {
+			j.Errors = append(j.Errors, newJSONError(me))
+		}
+	}
+	return j
+}
+
+func newJSONFrames(c Callers) []jsonFrame {
+	var r []jsonFrame
+	for _, f := range c.Frames() {
+		r = append(r, jsonFrame{
+			File:     f.File,
+			Line:     f.Line,
+			Function: f.Function,
+		})
+	}
+	return r
+}
@@ -0,0 +1,38 @@
+package xerrors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"testing"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{err: nil, want: `^null$`},
+		{err: Message("foo"), want: `^{"message":"foo"}$`},
+		{err: io.EOF, want: `^{"message":"EOF"}$`},
+		{err: WithWrapper(Message("foo"), Message("bar")), want: `^{"message":"foo: bar","causes":\[{"message":"foo"},{"message":"bar"}\]}$`},
+		{err: fmt.Errorf("foo: %w", io.EOF), want: `^{"message":"foo: EOF","causes":\[{"message":"EOF"}\]}$`},
+		{err: Append(Message("foo"), Message("bar")), want: `^{"message":"the following errors occurred: \[foo, bar\]","errors":\[{"message":"foo"},{"message":"bar"}\]}$`},
+		{err: New(Message("foo"), Message("bar")), want: `^{"message":"foo: bar","causes":\[{"message":"foo"},{"message":"bar"}\],"stack":\[{"file":"[^"]+json_test.go","line":[0-9]+,"function":"github.com/mdobak/go-xerrors.TestMarshalJSON"}`},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			b, err := MarshalJSON(tt.err)
+			if err != nil {
+				t.Fatalf("MarshalJSON(%#v): unexpected error: %v", tt.err, err)
+			}
+			if !json.Valid(b) {
+				t.Errorf("MarshalJSON(%#v): returned invalid JSON: %s", tt.err, b)
+			}
+			if match, _ := regexp.Match(tt.want, b); !match {
+				t.Errorf("MarshalJSON(%#v): %s does not match %q", tt.err, b, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,8 @@ package xerrors
 
 import (
 	"errors"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -50,7 +52,277 @@ func Append(err error, errs ...error) error {
 	}
 }
 
-const multiErrorErrorPrefix = "the following errors occurred: "
+// AppendUnique works like Append, but it skips errors that are already in
+// the list. An error is considered to be in the list if errors.Is returns
+// true for any error in the list and that error. Errors of types that are
+// not comparable are compared by their messages instead.
+//
+// Errors that are already duplicated in err are not removed.
+func AppendUnique(err error, errs ...error) error {
+	for _, e := range errs {
+		if e == nil || containsError(err, e) {
+			continue
+		}
+		err = Append(err, e)
+	}
+	return err
+}
+
+// containsError reports whether the list of errors contains target,
+// according to the rules of the AppendUnique function.
+func containsError(err, target error) bool {
+	canCompare := reflect.TypeOf(target).Comparable()
+	for i := 0; i < Len(err); i++ {
+		e := At(err, i)
+		if errors.Is(e, target) || (!canCompare && e.Error() == target.Error()) {
+			return true
+		}
+	}
+	return false
+}
+
+// AppendAny works like Append, but it accepts values of any type. Values
+// are converted to errors according to the same rules as in the New
+// function, so strings become message errors. Nil values are ignored. It
+// does not record a stack trace.
+func AppendAny(err error, vals ...interface{}) error {
+	errs := make([]error, 0, len(vals))
+	for _, val := range vals {
+		if val == nil || isNilError(val) {
+			continue
+		}
+		errs = append(errs, toError(val))
+	}
+	return Append(err, errs...)
+}
+
+// NewMulti creates a list of errors in the same way as the Append function,
+// and records a stack trace at the point it was called. Stack traces of the
+// errors in the list are kept. The list itself can be obtained using the
+// errors.Unwrap function.
+//
+// If the list is empty, nil is returned.
+func NewMulti(errs ...error) error {
+	err := Append(nil, errs...)
+	if err == nil {
+		return nil
+	}
+	return newWithStackTrace(err, 1)
+}
+
+// Filter returns a list of errors that contains only the errors from err for
+// which pred returns true. If err is not a list of errors, it is returned
+// unchanged if pred returns true, otherwise nil is returned. It does not
+// record a stack trace.
+//
+// The result is simplified in the same way as in the Append function, so if
+// the list is empty, nil is returned, and if the list contains only one
+// error, that error is returned instead of list.
+func Filter(err error, pred func(error) bool) error {
+	if err == nil {
+		return nil
+	}
+	me, ok := err.(multiError)
+	if !ok {
+		if pred(err) {
+			return err
+		}
+		return nil
+	}
+	var errs []error
+	for _, e := range me {
+		if pred(e) {
+			errs = append(errs, e)
+		}
+	}
+	return Append(nil, errs...)
+}
+
+// WrapEach replaces every error in a list of errors created by the Append
+// function with the result of fn, which is called with the index and the
+// error. If err is not a list of errors, the result of fn(0, err) is
+// returned. It does not record a stack trace.
+//
+// Unlike Append, the function always returns a list of errors if err is
+// a list of errors, even if it contains only one error. Nil errors returned
+// by fn are ignored, and if fn returns nil for every error, nil is returned.
+//
+// If err is nil, nil is returned.
+func WrapEach(err error, fn func(i int, err error) error) error {
+	if err == nil {
+		return nil
+	}
+	me, ok := err.(multiError)
+	if !ok {
+		return fn(0, err)
+	}
+	r := make(multiError, 0, len(me))
+	for i, e := range me {
+		if e = fn(i, e); e != nil {
+			r = append(r, e)
+		}
+	}
+	if len(r) == 0 {
+		return nil
+	}
+	return r
+}
+
+// Sort returns a copy of a list of errors created by the Append function,
+// sorted using the less function. The sort is stable. If err is not a list
+// of errors, it is returned unchanged. It does not record a stack trace.
+func Sort(err error, less func(a, b error) bool) error {
+	me, ok := err.(multiError)
+	if !ok {
+		return err
+	}
+	r := make(multiError, len(me))
+	copy(r, me)
+	sort.SliceStable(r, func(i, j int) bool {
+		return less(r[i], r[j])
+	})
+	return r
+}
+
+// ToChain converts a list of errors into a chain of errors, in which every
+// error is wrapped by the previous one, as if they were combined using the
+// WithWrapper function. The message of the returned error contains the
+// messages of all errors separated by ": ", instead of a bracketed list.
+// The errors.Is and errors.As functions work with all of the errors.
+//
+// If err is not a list of errors, it is returned unchanged.
+func ToChain(err error) error {
+	me, ok := err.(multiError)
+	if !ok || len(me) == 0 {
+		return err
+	}
+	chain := me[0]
+	for _, e := range me[1:] {
+		chain = &withWrapper{
+			wrapper: chain,
+			err:     e,
+		}
+	}
+	return chain
+}
+
+// ToMulti converts a chain of errors into a list of errors that contains
+// the errors returned by the Causes function. It can be used to present
+// errors combined using the WithWrapper or New functions as a list. Stack
+// traces of the chain are not retained.
+//
+// If the chain contains only one error, err is returned unchanged.
+func ToMulti(err error) error {
+	causes := Causes(err)
+	if len(causes) <= 1 {
+		return err
+	}
+	return multiError(causes)
+}
+
+// SameErrors reports whether a and b contain the same errors, regardless of
+// their order. Lists of errors created by the Append function are treated as
+// sets of their errors, and other errors as sets with a single error. The
+// result is true if every error from a matches some error from b, and every
+// error from b matches some error from a. Two errors match if errors.Is
+// returns true for them in either order, so an error matches the same error
+// with a stack trace.
+//
+// It is useful in tests that compare lists of errors created in an
+// undefined order, for example by the ErrorGroup type.
+func SameErrors(a, b error) bool {
+	return containsAll(a, b) && containsAll(b, a)
+}
+
+// containsAll reports whether every error from b matches some error from a,
+// according to the rules of the SameErrors function.
+func containsAll(a, b error) bool {
+	for i := 0; i < Len(b); i++ {
+		found := false
+		for j := 0; j < Len(a); j++ {
+			if errors.Is(At(b, i), At(a, j)) || errors.Is(At(a, j), At(b, i)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Len returns the number of errors in a list of errors created by the Append
+// function. If err is not a list of errors, 1 is returned. If err is nil,
+// 0 is returned.
+func Len(err error) int {
+	if err == nil {
+		return 0
+	}
+	if me, ok := err.(multiError); ok {
+		return len(me)
+	}
+	return 1
+}
+
+// At returns the i-th error from a list of errors created by the Append
+// function. If err is not a list of errors, it is treated as a list with
+// a single error. If i is out of range, nil is returned.
+func At(err error, i int) error {
+	if i < 0 || i >= Len(err) {
+		return nil
+	}
+	if me, ok := err.(multiError); ok {
+		return me[i]
+	}
+	return err
+}
+
+var multiErrorPrintLimit int
+
+// SetMultiErrorPrintLimit sets the maximum number of errors from a list of
+// errors that are printed in the error details. The remaining errors are
+// replaced by a single line with their count. If n is 0 or less, all errors
+// are printed, which is the default.
+//
+// The limit only affects the error details. It does not change the errors
+// contained in the list.
+//
+// This function is not thread-safe and should be called only once, during
+// the program initialization.
+func SetMultiErrorPrintLimit(n int) {
+	multiErrorPrintLimit = n
+}
+
+var multiErrorPrefix = "the following errors occurred: "
+
+// SetMultiErrorPrefix sets the prefix of the message of lists of errors
+// created by the Append function. The prefix is followed by the messages of
+// all errors in the list, enclosed in square brackets. The default prefix is
+// "the following errors occurred: ".
+//
+// This function is not thread-safe and should be called only once, during
+// the program initialization.
+func SetMultiErrorPrefix(prefix string) {
+	multiErrorPrefix = prefix
+}
+
+var multiErrorListLimit int
+
+// SetMultiErrorListLimit sets the maximum number of errors whose messages
+// are included in the message of a list of errors. If a list contains more
+// errors, its message only contains their count, for example "42 errors
+// occurred". If n is 0 or less, the messages of all errors are included,
+// which is the default.
+//
+// The limit only affects the result of the Error method. The error details
+// are limited by the SetMultiErrorPrintLimit function.
+//
+// This function is not thread-safe and should be called only once, during
+// the program initialization.
+func SetMultiErrorListLimit(n int) {
+	multiErrorListLimit = n
+}
 
 // multiError is a slice of errors that can be used as a single error.
 type multiError []error
@@ -58,7 +330,12 @@ type multiError []error
 // Error implements the error interface.
 func (e multiError) Error() string {
 	s := &strings.Builder{}
-	s.WriteString(multiErrorErrorPrefix)
+	if multiErrorListLimit > 0 && len(e) > multiErrorListLimit {
+		s.WriteString(strconv.Itoa(len(e)))
+		s.WriteString(" errors occurred")
+		return s.String()
+	}
+	s.WriteString(multiErrorPrefix)
 	s.WriteString("[")
 	for n, err := range e {
 		s.WriteString(err.Error())
@@ -72,11 +349,22 @@ func (e multiError) Error() string {
 
 // ErrorDetails implements the DetailedError interface.
 func (e multiError) ErrorDetails() string {
+	return e.errorDetails(newPrinter())
+}
+
+func (e multiError) errorDetails(p printer) string {
 	s := &strings.Builder{}
-	for n, err := range e.Errors() {
+	for n, err := range e {
+		if multiErrorPrintLimit > 0 && n >= multiErrorPrintLimit {
+			s.WriteString("... and ")
+			s.WriteString(strconv.Itoa(len(e) - n))
+			s.WriteString(" more errors")
+			s.WriteString(lineEnding)
+			break
+		}
 		s.WriteString(strconv.Itoa(n + 1))
 		s.WriteString(". ")
-		s.WriteString(indent(Sprint(err)))
+		s.WriteString(indent(p.sprint(err)))
 	}
 	return s.String()
 }
@@ -106,12 +394,13 @@ func (e multiError) Is(target error) bool {
 	return false
 }
 
-// indent idents every line, except the first one, with tab.
+// indent idents every line, except the first one, with the string set by
+// the SetIndent function.
 func indent(s string) string {
 	end := ""
-	if strings.HasSuffix(s, "\n") {
-		end = "\n"
-		s = s[:len(s)-1]
+	if strings.HasSuffix(s, lineEnding) {
+		end = lineEnding
+		s = s[:len(s)-len(lineEnding)]
 	}
-	return strings.ReplaceAll(s, "\n", "\n\t") + end
+	return strings.ReplaceAll(s, lineEnding, lineEnding+indentString) + end
 }
@@ -3,6 +3,7 @@ package xerrors
 import (
 	"errors"
 	"fmt"
+	"io"
 	"reflect"
 	"testing"
 )
@@ -80,3 +81,363 @@ func TestMultiError_ErrorDetails(t *testing.T) {
 		})
 	}
 }
+
+type sliceErr []string
+
+func (e sliceErr) Error() string {
+	return fmt.Sprint([]string(e))
+}
+
+func TestAppendUnique(t *testing.T) {
+	timeout := Message("timeout")
+	tests := []struct {
+		err     error
+		errs    []error
+		want    string
+		wantNil bool
+	}{
+		{err: nil, errs: nil, wantNil: true},
+		{err: nil, errs: []error{timeout, timeout, nil}, want: "timeout"},
+		{err: timeout, errs: []error{timeout, io.EOF, io.EOF, timeout}, want: "the following errors occurred: [timeout, EOF]"},
+		{err: New(timeout), errs: []error{timeout}, want: "timeout"},
+		{err: Append(timeout, timeout), errs: []error{timeout}, want: "the following errors occurred: [timeout, timeout]"},
+		{err: sliceErr{"a"}, errs: []error{sliceErr{"a"}, sliceErr{"b"}}, want: "the following errors occurred: [[a], [b]]"},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			got := AppendUnique(tt.err, tt.errs...)
+			if tt.wantNil {
+				if got != nil {
+					t.Errorf("AppendUnique(%#v, %#v): expected nil", tt.err, tt.errs)
+				}
+				return
+			}
+			if got == nil || got.Error() != tt.want {
+				t.Errorf("AppendUnique(%#v, %#v): got: %v, want %q", tt.err, tt.errs, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppendAny(t *testing.T) {
+	tests := []struct {
+		err     error
+		vals    []interface{}
+		want    string
+		wantNil bool
+	}{
+		{err: nil, vals: nil, wantNil: true},
+		{err: nil, vals: []interface{}{nil, (*ptrErr)(nil)}, wantNil: true},
+		{err: nil, vals: []interface{}{"foo"}, want: "foo"},
+		{err: Message("foo"), vals: []interface{}{nil}, want: "foo"},
+		{err: Message("foo"), vals: []interface{}{"bar", stringer{s: "baz"}, 42}, want: "the following errors occurred: [foo, bar, baz, 42]"},
+		{err: Append(Message("foo"), Message("bar")), vals: []interface{}{io.EOF}, want: "the following errors occurred: [foo, bar, EOF]"},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			got := AppendAny(tt.err, tt.vals...)
+			if tt.wantNil {
+				if got != nil {
+					t.Errorf("AppendAny(%#v, %#v): expected nil", tt.err, tt.vals)
+				}
+				return
+			}
+			if got == nil || got.Error() != tt.want {
+				t.Errorf("AppendAny(%#v, %#v): got: %v, want %q", tt.err, tt.vals, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewMulti(t *testing.T) {
+	if err := NewMulti(); err != nil {
+		t.Errorf("NewMulti(): must return nil")
+	}
+	if err := NewMulti(nil, nil); err != nil {
+		t.Errorf("NewMulti(nil, nil): must return nil")
+	}
+	a, b := New("a"), Message("b")
+	err := NewMulti(a, nil, b)
+	if got, want := err.Error(), "the following errors occurred: [a, b]"; got != want {
+		t.Errorf("NewMulti(a, nil, b).Error(): %q does not match %q", got, want)
+	}
+	if n := Len(errors.Unwrap(err)); n != 2 {
+		t.Errorf("Len(errors.Unwrap(NewMulti(a, nil, b))): got: %d, want: 2", n)
+	}
+	if !errors.Is(err, a) || !errors.Is(err, b) {
+		t.Errorf("errors.Is(NewMulti(a, nil, b), a|b): must return true")
+	}
+	frame, ok := Origin(err)
+	if !ok || frame.Function != "github.com/mdobak/go-xerrors.TestNewMulti" {
+		t.Errorf("Origin(NewMulti(a, nil, b)): got: %q, want the stack trace of NewMulti", frame.Function)
+	}
+	if StackTrace(At(errors.Unwrap(err), 0)).Fingerprint() != StackTrace(a).Fingerprint() {
+		t.Errorf("NewMulti(a, nil, b): stack traces of the errors must be kept")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	a, b, c := Message("a"), Message("b"), Message("c")
+	notB := func(err error) bool { return !errors.Is(err, b) }
+	tests := []struct {
+		err     error
+		want    string
+		wantNil bool
+	}{
+		{err: nil, wantNil: true},
+		{err: a, want: "a"},
+		{err: b, wantNil: true},
+		{err: Append(a, b, c), want: "the following errors occurred: [a, c]"},
+		{err: Append(a, b), want: "a"},
+		{err: Append(b, b), wantNil: true},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			got := Filter(tt.err, notB)
+			if tt.wantNil {
+				if got != nil {
+					t.Errorf("Filter(%#v, pred): got: %q, want nil", tt.err, got)
+				}
+				return
+			}
+			if got == nil || got.Error() != tt.want {
+				t.Errorf("Filter(%#v, pred): got: %v, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToChainToMulti(t *testing.T) {
+	a, b, c := Message("a"), Message("b"), Message("c")
+	tests := []struct {
+		err       error
+		wantChain string
+		wantMulti string
+	}{
+		{err: a, wantChain: "a", wantMulti: "a"},
+		{err: Append(a, b, c), wantChain: "a: b: c", wantMulti: "the following errors occurred: [a, b, c]"},
+		{err: New(a, b, c), wantChain: "a: b: c", wantMulti: "the following errors occurred: [a, b, c]"},
+		{err: WithWrapper(a, Append(b, c)), wantChain: "a: the following errors occurred: [b, c]", wantMulti: "the following errors occurred: [a, the following errors occurred: [b, c]]"},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			chain := ToChain(tt.err)
+			if got := chain.Error(); got != tt.wantChain {
+				t.Errorf("ToChain(%#v): got: %q, want: %q", tt.err, got, tt.wantChain)
+			}
+			multi := ToMulti(chain)
+			if got := multi.Error(); got != tt.wantMulti {
+				t.Errorf("ToMulti(ToChain(%#v)): got: %q, want: %q", tt.err, got, tt.wantMulti)
+			}
+			for _, target := range []error{a, b, c} {
+				if errors.Is(tt.err, target) != errors.Is(chain, target) || errors.Is(tt.err, target) != errors.Is(multi, target) {
+					t.Errorf("errors.Is(%#v, %#v): conversions must preserve the result", tt.err, target)
+				}
+			}
+		})
+	}
+	if got, want := ToMulti(WithCode(New(a, b), 1)).Error(), "the following errors occurred: [a, b]"; got != want {
+		t.Errorf("ToMulti(WithCode(New(a, b), 1)): got: %q, want: %q", got, want)
+	}
+	if ToChain(nil) != nil || ToMulti(nil) != nil {
+		t.Errorf("ToChain(nil), ToMulti(nil): must return nil")
+	}
+}
+
+func TestSameErrors(t *testing.T) {
+	a, b, c := Message("a"), Message("b"), Message("c")
+	tests := []struct {
+		a, b error
+		want bool
+	}{
+		{a: nil, b: nil, want: true},
+		{a: a, b: nil, want: false},
+		{a: a, b: a, want: true},
+		{a: a, b: New(a), want: true},
+		{a: a, b: b, want: false},
+		{a: Append(a, b), b: Append(b, a), want: true},
+		{a: Append(a, b), b: Append(New(b), a, a), want: true},
+		{a: Append(a, b), b: Append(a, b, c), want: false},
+		{a: Append(a, b), b: a, want: false},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			if got := SameErrors(tt.a, tt.b); got != tt.want {
+				t.Errorf("SameErrors(%v, %v): got: %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+			if got := SameErrors(tt.b, tt.a); got != tt.want {
+				t.Errorf("SameErrors(%v, %v): got: %v, want %v", tt.b, tt.a, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLenAt(t *testing.T) {
+	a, b := Message("a"), Message("b")
+	tests := []struct {
+		err  error
+		want []error
+	}{
+		{err: nil, want: nil},
+		{err: a, want: []error{a}},
+		{err: Append(a, b), want: []error{a, b}},
+		{err: multiError{}, want: nil},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			if got := Len(tt.err); got != len(tt.want) {
+				t.Errorf("Len(%#v): got: %d, want %d", tt.err, got, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if got := At(tt.err, i); got != want {
+					t.Errorf("At(%#v, %d): got: %#v, want %#v", tt.err, i, got, want)
+				}
+			}
+			if got := At(tt.err, -1); got != nil {
+				t.Errorf("At(%#v, -1): got: %#v, want nil", tt.err, got)
+			}
+			if got := At(tt.err, len(tt.want)); got != nil {
+				t.Errorf("At(%#v, %d): got: %#v, want nil", tt.err, len(tt.want), got)
+			}
+		})
+	}
+}
+
+func TestWrapEach(t *testing.T) {
+	a, b := Message("a"), Message("b")
+	prefix := func(i int, err error) error {
+		return WithWrapper(Message(fmt.Sprintf("task %d", i)), err)
+	}
+	tests := []struct {
+		err     error
+		want    string
+		wantLen int
+	}{
+		{err: nil, want: "", wantLen: 0},
+		{err: a, want: "task 0: a", wantLen: 1},
+		{err: Append(a, b), want: "the following errors occurred: [task 0: a, task 1: b]", wantLen: 2},
+		{err: multiError{a}, want: "the following errors occurred: [task 0: a]", wantLen: 1},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			got := WrapEach(tt.err, prefix)
+			if tt.err == nil {
+				if got != nil {
+					t.Errorf("WrapEach(nil, fn): must return nil")
+				}
+				return
+			}
+			if got.Error() != tt.want {
+				t.Errorf("WrapEach(%#v, fn): got: %q, want %q", tt.err, got, tt.want)
+			}
+			if Len(got) != tt.wantLen {
+				t.Errorf("Len(WrapEach(%#v, fn)): got: %d, want %d", tt.err, Len(got), tt.wantLen)
+			}
+			if len(StackTrace(got)) != 0 {
+				t.Errorf("WrapEach(%#v, fn): returned error must not contain a stack trace", tt.err)
+			}
+			if !errors.Is(got, At(tt.err, 0)) {
+				t.Errorf("errors.Is(WrapEach(%#v, fn), err): must return true for wrapped errors", tt.err)
+			}
+		})
+	}
+	drop := func(int, error) error { return nil }
+	if got := WrapEach(Append(a, b), drop); got != nil {
+		t.Errorf("WrapEach(Append(a, b), fn): got: %#v, want nil if fn returns nil for every error", got)
+	}
+}
+
+func TestSetMultiErrorPrintLimit(t *testing.T) {
+	defer SetMultiErrorPrintLimit(0)
+
+	err := multiError{Message("a"), Message("b"), Message("c")}
+	tests := []struct {
+		limit int
+		want  string
+	}{
+		{limit: 0, want: "1. Error: a\n2. Error: b\n3. Error: c\n"},
+		{limit: 1, want: "1. Error: a\n... and 2 more errors\n"},
+		{limit: 2, want: "1. Error: a\n2. Error: b\n... and 1 more errors\n"},
+		{limit: 3, want: "1. Error: a\n2. Error: b\n3. Error: c\n"},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			SetMultiErrorPrintLimit(tt.limit)
+			if got := err.ErrorDetails(); got != tt.want {
+				t.Errorf("multiError(errs).ErrorDetails(): %q does not match %q", got, tt.want)
+			}
+			if Len(err) != 3 {
+				t.Errorf("Len(multiError(errs)): limit must not affect the number of errors")
+			}
+		})
+	}
+}
+
+func TestSetMultiErrorPrefix(t *testing.T) {
+	defer SetMultiErrorPrefix("the following errors occurred: ")
+
+	err := multiError{Message("a"), Message("b")}
+	SetMultiErrorPrefix("errors: ")
+	if got, want := err.Error(), "errors: [a, b]"; got != want {
+		t.Errorf("multiError(errs).Error(): %q does not match %q", got, want)
+	}
+}
+
+func TestSetMultiErrorListLimit(t *testing.T) {
+	defer SetMultiErrorListLimit(0)
+
+	err := multiError{Message("a"), Message("b"), Message("c")}
+	tests := []struct {
+		limit int
+		want  string
+	}{
+		{limit: 0, want: "the following errors occurred: [a, b, c]"},
+		{limit: 1, want: "3 errors occurred"},
+		{limit: 2, want: "3 errors occurred"},
+		{limit: 3, want: "the following errors occurred: [a, b, c]"},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			SetMultiErrorListLimit(tt.limit)
+			if got := err.Error(); got != tt.want {
+				t.Errorf("multiError(errs).Error(): %q does not match %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSort(t *testing.T) {
+	byMessage := func(a, b error) bool { return a.Error() < b.Error() }
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{err: nil, want: ""},
+		{err: Message("a"), want: "a"},
+		{err: Append(Message("c"), Message("a"), Message("b")), want: "the following errors occurred: [a, b, c]"},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			var before string
+			if tt.err != nil {
+				before = tt.err.Error()
+			}
+			got := Sort(tt.err, byMessage)
+			if tt.err == nil {
+				if got != nil {
+					t.Errorf("Sort(nil, less): must return nil")
+				}
+				return
+			}
+			if got.Error() != tt.want {
+				t.Errorf("Sort(%#v, less): got: %q, want %q", tt.err, got, tt.want)
+			}
+			if tt.err.Error() != before {
+				t.Errorf("Sort(%#v, less): must not modify the original error", tt.err)
+			}
+			if len(StackTrace(got)) != 0 {
+				t.Errorf("Sort(%#v, less): returned error must not contain a stack trace", tt.err)
+			}
+		})
+	}
+}
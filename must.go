@@ -0,0 +1,50 @@
+//go:build go1.18
+// +build go1.18
+
+package xerrors
+
+import (
+	"errors"
+)
+
+// Must returns v if err is nil, otherwise it panics. The panic value is an
+// error that contains a PanicError wrapping err, with a stack trace recorded
+// at the point Must was called. If the panic is recovered using the Recover,
+// RecoverTo or FromRecover functions, the panic value is returned as is,
+// and err can be obtained using errors.Is, errors.As and PanicValue.
+//
+// This function is intended for initialization code, where errors cannot be
+// handled in any other way:
+//
+//	var tmpl = xerrors.Must(template.ParseFiles("index.html"))
+func Must[T any](v T, err error) T {
+	if err != nil {
+		panic(newMustError(err, 1))
+	}
+	return v
+}
+
+// newMustError creates the value used by the Must function to panic. It is
+// marked, so that the Recover, RecoverTo and FromRecover functions return it
+// without adding another PanicError.
+func newMustError(err error, skip int) error {
+	e := newWithStackTrace(nil, skip+1)
+	e.err = &panicError{panic: err, stack: e.stack, must: true}
+	return e
+}
+
+// PanicValue finds the first PanicError in the error chain of err and
+// returns its panic value converted to T. If there is no PanicError, or the
+// value is not of type T, the zero value of T and false are returned.
+//
+//	v, ok := xerrors.PanicValue[MyStruct](err)
+func PanicValue[T any](err error) (T, bool) {
+	var pe PanicError
+	if errors.As(err, &pe) {
+		if v, ok := pe.Panic().(T); ok {
+			return v, true
+		}
+	}
+	var zero T
+	return zero, false
+}
@@ -0,0 +1,93 @@
+//go:build go1.18
+// +build go1.18
+
+package xerrors
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMust(t *testing.T) {
+	if got := Must(42, nil); got != 42 {
+		t.Errorf("Must(42, nil): got: %d, want: 42", got)
+	}
+	err := func() (err error) {
+		defer RecoverTo(&err)
+		Must(42, io.EOF)
+		return nil
+	}()
+	if err == nil {
+		t.Fatalf("Must(42, io.EOF): must panic")
+	}
+	if got, want := err.Error(), "panic: EOF"; got != want {
+		t.Errorf("Must(42, io.EOF): got: %q, want: %q", got, want)
+	}
+	if !errors.Is(err, io.EOF) {
+		t.Errorf("errors.Is(err, io.EOF): must return true")
+	}
+	var pe PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("errors.As(err, &pe): must return true")
+	}
+	if pe.Panic() != io.EOF {
+		t.Errorf("pe.Panic(): got: %#v, want: io.EOF", pe.Panic())
+	}
+	if frame, _ := Origin(err); frame.Function != "github.com/mdobak/go-xerrors.TestMust.func1" {
+		t.Errorf("Must(42, io.EOF): the stack trace must start at the Must call site, got: %q", frame.Function)
+	}
+	if v, ok := PanicValue[error](err); !ok || v != io.EOF {
+		t.Errorf("PanicValue[error](err): got: (%v, %v), want: (EOF, true)", v, ok)
+	}
+	details := Sprint(err)
+	if n := strings.Count(details, "Previous error:"); n != 0 {
+		t.Errorf("Sprint(err): %q must contain a single error, got %d previous errors", details, n)
+	}
+	if !strings.Contains(details, "panic value of type *errors.errorString") {
+		t.Errorf("Sprint(err): %q must contain the type of the error passed to Must", details)
+	}
+}
+
+func TestMustRepanic(t *testing.T) {
+	err := func() (err error) {
+		defer RecoverTo(&err)
+		Must(42, io.EOF)
+		return nil
+	}()
+	got := func() (got error) {
+		defer RecoverTo(&got)
+		panic(err)
+	}()
+	var pe PanicError
+	if !errors.As(got, &pe) || pe.Panic() != err {
+		t.Errorf("RecoverTo(&got): must record the panic of an error returned by Must")
+	}
+	if !errors.Is(got, io.EOF) {
+		t.Errorf("errors.Is(got, io.EOF): must return true")
+	}
+}
+
+func TestPanicValue(t *testing.T) {
+	type value struct{ n int }
+	recovered := func(v interface{}) (err error) {
+		defer RecoverTo(&err)
+		panic(v)
+	}
+	if v, ok := PanicValue[value](recovered(value{n: 42})); !ok || v.n != 42 {
+		t.Errorf("PanicValue[value](err): got: (%v, %v), want: ({42}, true)", v, ok)
+	}
+	if v, ok := PanicValue[string](recovered(value{n: 42})); ok || v != "" {
+		t.Errorf("PanicValue[string](err): got: (%q, %v), want: (\"\", false)", v, ok)
+	}
+	if v, ok := PanicValue[error](recovered(io.EOF)); !ok || v != io.EOF {
+		t.Errorf("PanicValue[error](err): got: (%v, %v), want: (EOF, true)", v, ok)
+	}
+	if _, ok := PanicValue[value](New("foo")); ok {
+		t.Errorf("PanicValue[value](New(\"foo\")): must return false")
+	}
+	if _, ok := PanicValue[value](nil); ok {
+		t.Errorf("PanicValue[value](nil): must return false")
+	}
+}
@@ -1,7 +1,10 @@
 package xerrors
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
+	"runtime"
 )
 
 // Recover wraps the recover() built-in and converts a value returned by it to
@@ -12,10 +15,28 @@ import (
 // Otherwise, it will not work.
 func Recover(fn func(err error)) {
 	if r := recover(); r != nil {
-		fn(&withStackTrace{
-			err:   &panicError{panic: r},
-			stack: callers(2),
-		})
+		fn(newPanicError(r, 2))
+	}
+}
+
+// RecoverTo wraps the recover() built-in and converts a value returned by it
+// to an error with a stack trace, which is then assigned to the variable
+// pointed to by errp. If the variable already contains an error, both errors
+// are combined using the Append function. If there is no panic, the variable
+// is left untouched.
+//
+// It is intended to be used with named return values:
+//
+//	func fn() (err error) {
+//		defer xerrors.RecoverTo(&err)
+//		// ...
+//	}
+//
+// This function must always be used *directly* with the "defer" keyword.
+// Otherwise, it will not work.
+func RecoverTo(errp *error) {
+	if r := recover(); r != nil {
+		*errp = Append(*errp, newPanicError(r, 2))
 	}
 }
 
@@ -28,24 +49,128 @@ func FromRecover(r interface{}) error {
 	if r == nil {
 		return nil
 	}
-	return &withStackTrace{
-		err:   &panicError{panic: r},
-		stack: callers(3),
+	return newPanicError(r, 3)
+}
+
+// Go runs fn in a new goroutine. If fn returns an error, or panics, then
+// onErr is invoked with that error. Panics are converted to errors in the
+// same way as in the Recover function, so the stack trace points to the
+// place where the panic occurred.
+//
+// The onErr callback is invoked from the goroutine that runs fn.
+func Go(fn func() error, onErr func(err error)) {
+	go func() {
+		defer Recover(onErr)
+		if err := fn(); err != nil {
+			onErr(err)
+		}
+	}()
+}
+
+// Guard returns a function that calls fn and returns its result. If fn
+// panics, the panic is recovered and returned as an error, in the same way
+// as in the Recover function, so the stack trace points to the place where
+// the panic occurred.
+func Guard(fn func() error) func() error {
+	return func() (err error) {
+		defer RecoverTo(&err)
+		return fn()
+	}
+}
+
+// Repanic panics with the value that was used to create the given error.
+//
+// If err was created by the Recover or FromRecover functions, then the
+// original value passed to panic is used, so that recover() further up the
+// stack returns the same value as it would if the panic had never been
+// recovered. Otherwise, Repanic panics with err itself.
+func Repanic(err error) {
+	var pe *panicError
+	if errors.As(err, &pe) {
+		panic(pe.panic)
+	}
+	panic(err)
+}
+
+// newPanicError creates an error with a stack trace from a value returned
+// by the recover() built-in. The stack trace is recorded after skipping skip
+// frames, as in the WithStackTrace function. If the value was created by the
+// Must function, it already contains a PanicError with a stack trace, so a
+// copy of it is returned instead. The copy is no longer marked as created by
+// the Must function, so if it is used to panic again, the new panic is
+// recorded.
+func newPanicError(r interface{}, skip int) error {
+	if e, ok := r.(*withStackTrace); ok {
+		if pe, ok := e.err.(*panicError); ok && pe.must {
+			c := *e
+			c.err = &panicError{panic: pe.panic, stack: pe.stack}
+			return &c
+		}
 	}
+	e := newWithStackTrace(nil, skip+1)
+	e.err = &panicError{panic: r, stack: e.stack}
+	return e
 }
 
 // panicError is an error constructed from a value returned by the recover()
 // built-in during panicking.
 type panicError struct {
 	panic interface{}
+	stack Callers
+	must  bool // created by the Must function
 }
 
-// Panic returns the value from the recover() function.
+// Panic implements the PanicError interface.
 func (e *panicError) Panic() interface{} {
 	return e.panic
 }
 
-// Error implements the error interface.
+// IsRuntimeError implements the PanicError interface.
+func (e *panicError) IsRuntimeError() bool {
+	_, ok := e.panic.(runtime.Error)
+	return ok
+}
+
+// PanicType implements the PanicError interface. It returns the name of the
+// type of the panic value, for example "string" or "runtime.boundsError",
+// or "nil" if the value is nil.
+func (e *panicError) PanicType() string {
+	if e.panic == nil {
+		return "nil"
+	}
+	return reflect.TypeOf(e.panic).String()
+}
+
+// ErrorDetails implements the DetailedError interface.
+func (e *panicError) ErrorDetails() string {
+	return indentString + "panic value of type " + e.PanicType() + lineEnding
+}
+
+// Unwrap implements the Wrapper interface. If the value returned by the
+// recover() built-in is an error, that error is returned, otherwise nil.
+func (e *panicError) Unwrap() error {
+	if err, ok := e.panic.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// StackTrace implements the StackTracer interface.
+func (e *panicError) StackTrace() Callers {
+	return e.stack
+}
+
+// Error implements the error interface. If the panic value is an error
+// that already contains a PanicError, for example when an error returned by
+// the Recover function is used to invoke panic again, its message is
+// returned as is, to avoid repeating the "panic: " prefix.
 func (e *panicError) Error() string {
+	if err, ok := e.panic.(error); ok {
+		var pe PanicError
+		if errors.As(err, &pe) {
+			return err.Error()
+		}
+		return "panic: " + err.Error()
+	}
 	return fmt.Sprintf("panic: %v", e.panic)
 }
@@ -3,8 +3,13 @@ package xerrors
 import (
 	"errors"
 	"fmt"
+	"io"
+	"reflect"
 	"regexp"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestRecover(t *testing.T) {
@@ -119,3 +124,314 @@ func TestPanicErrorFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestGo(t *testing.T) {
+	tests := []struct {
+		fn      func() error
+		want    string
+		wantErr bool
+	}{
+		{fn: func() error { return nil }, wantErr: false},
+		{fn: func() error { return Message("foo") }, want: "foo", wantErr: true},
+		{fn: func() error { panic("foo") }, want: "panic: foo", wantErr: true},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			ch := make(chan error, 1)
+			Go(tt.fn, func(err error) {
+				ch <- err
+			})
+			if !tt.wantErr {
+				select {
+				case got := <-ch:
+					t.Errorf("Go(fn, onErr): onErr must not be called, got %q", got)
+				case <-time.After(10 * time.Millisecond):
+				}
+				return
+			}
+			var got error
+			select {
+			case got = <-ch:
+			case <-time.After(time.Second):
+				t.Fatalf("Go(fn, onErr): onErr must be called")
+			}
+			if got.Error() != tt.want {
+				t.Errorf("Go(fn, onErr): got: %q, want %q", got, tt.want)
+			}
+			panicErr := &panicError{}
+			if errors.As(got, &panicErr) {
+				fnName := runtime.FuncForPC(reflect.ValueOf(tt.fn).Pointer()).Name()
+				st := StackTrace(got)
+				if len(st) == 0 || st.Frames()[0].Function != fnName {
+					t.Errorf("Go(fn, onErr): the first frame of stack trace must start at %s", fnName)
+				}
+			}
+		})
+	}
+}
+
+func TestGuard(t *testing.T) {
+	tests := []struct {
+		fn        func() error
+		want      string
+		wantPanic bool
+	}{
+		{fn: func() error { return nil }},
+		{fn: func() error { return Message("foo") }, want: "foo"},
+		{fn: func() error { panic("foo") }, want: "panic: foo", wantPanic: true},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			got := Guard(tt.fn)()
+			if tt.want == "" {
+				if got != nil {
+					t.Errorf("Guard(fn)(): got: %q, want nil", got)
+				}
+				return
+			}
+			if got == nil || got.Error() != tt.want {
+				t.Fatalf("Guard(fn)(): got: %v, want %q", got, tt.want)
+			}
+			var pe PanicError
+			if errors.As(got, &pe) != tt.wantPanic {
+				t.Errorf("errors.As(Guard(fn)(), &pe): got: %v, want %v", !tt.wantPanic, tt.wantPanic)
+			}
+			if tt.wantPanic {
+				fnName := runtime.FuncForPC(reflect.ValueOf(tt.fn).Pointer()).Name()
+				st := StackTrace(got)
+				if len(st) == 0 || st.Frames()[0].Function != fnName {
+					t.Errorf("Guard(fn)(): the first frame of stack trace must start at %s", fnName)
+				}
+			}
+		})
+	}
+}
+
+func TestRepanic(t *testing.T) {
+	tests := []struct {
+		err  func() error
+		want interface{}
+	}{
+		{err: func() (err error) {
+			defer Recover(func(r error) { err = r })
+			panic("foo")
+		}, want: "foo"},
+		{err: func() (err error) {
+			defer Recover(func(r error) { err = New("bar", r) })
+			panic(42)
+		}, want: 42},
+		{err: func() error { return io.EOF }, want: io.EOF},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			err := tt.err()
+			defer func() {
+				if got := recover(); got != tt.want {
+					t.Errorf("Repanic(%#v): got: %#v, want %#v", err, got, tt.want)
+				}
+			}()
+			Repanic(err)
+		})
+	}
+}
+
+func TestRecoverTo(t *testing.T) {
+	tests := []struct {
+		panic interface{}
+		err   error
+		want  string
+	}{
+		{panic: nil, err: nil, want: ""},
+		{panic: nil, err: Message("foo"), want: "foo"},
+		{panic: "foo", err: nil, want: "panic: foo"},
+		{panic: 42, err: Message("foo"), want: "the following errors occurred: [foo, panic: 42]"},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			fn := func() (err error) {
+				defer RecoverTo(&err)
+				err = tt.err
+				if tt.panic != nil {
+					panic(tt.panic)
+				}
+				return err
+			}
+			got := fn()
+			if tt.want == "" {
+				if got != nil {
+					t.Errorf("RecoverTo(&err): got: %q, want nil", got)
+				}
+				return
+			}
+			if got.Error() != tt.want {
+				t.Errorf("RecoverTo(&err): got: %q, want %q", got, tt.want)
+			}
+			if tt.panic == nil {
+				return
+			}
+			panicErr := &panicError{}
+			if !errors.As(got, &panicErr) || panicErr.Panic() != tt.panic {
+				t.Errorf("RecoverTo(&err): the value returned by Panic method must be the same as the value used to invoke panic")
+			}
+			var st Callers
+			if me, ok := got.(MultiError); ok {
+				st = StackTrace(me.Errors()[1])
+			} else {
+				st = StackTrace(got)
+			}
+			if len(st) == 0 || shortname(st.Frames()[0].Function) != "go-xerrors.TestRecoverTo.func1.1" {
+				t.Errorf("RecoverTo(&err): the first frame of stack trace must start at xerrors.TestRecoverTo.func1.1")
+			}
+		})
+	}
+}
+
+func TestPanicErrorStackTrace(t *testing.T) {
+	var err error
+	func() {
+		defer Recover(func(r error) { err = New("foo", r) })
+		panic("bar")
+	}()
+	var pe PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("errors.As(err, &PanicError): must return true")
+	}
+	if pe.Panic() != "bar" {
+		t.Errorf("PanicError.Panic(): got: %#v, want %#v", pe.Panic(), "bar")
+	}
+	st := pe.StackTrace()
+	if len(st) == 0 || shortname(st.Frames()[0].Function) != "go-xerrors.TestPanicErrorStackTrace.func1" {
+		t.Errorf("PanicError.StackTrace(): the first frame of stack trace must start at xerrors.TestPanicErrorStackTrace.func1")
+	}
+	if StackTrace(err).String() == st.String() {
+		t.Errorf("PanicError.StackTrace(): must return the stack trace of the panic, not the outer error")
+	}
+}
+
+func TestPanicErrorRuntimeError(t *testing.T) {
+	tests := []struct {
+		fn          func()
+		wantRuntime bool
+		wantIs      error
+	}{
+		{fn: func() { panic("foo") }, wantRuntime: false},
+		{fn: func() { panic(io.EOF) }, wantRuntime: false, wantIs: io.EOF},
+		{fn: func() {
+			var m map[string]int
+			m["foo"] = 42
+		}, wantRuntime: true},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			var err error
+			func() {
+				defer Recover(func(r error) { err = r })
+				tt.fn()
+			}()
+			var pe PanicError
+			if !errors.As(err, &pe) {
+				t.Fatalf("errors.As(err, &PanicError): must return true")
+			}
+			if got := pe.IsRuntimeError(); got != tt.wantRuntime {
+				t.Errorf("PanicError.IsRuntimeError(): got: %v, want %v", got, tt.wantRuntime)
+			}
+			var re runtime.Error
+			if got := errors.As(err, &re); got != tt.wantRuntime {
+				t.Errorf("errors.As(err, &runtime.Error): got: %v, want %v", got, tt.wantRuntime)
+			}
+			if tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+				t.Errorf("errors.Is(err, %#v): must return true", tt.wantIs)
+			}
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Errorf("errors.Is(err, io.ErrUnexpectedEOF): must return false")
+			}
+		})
+	}
+}
+
+func TestPanicErrorUnwrap(t *testing.T) {
+	tests := []struct {
+		panic interface{}
+		want  error
+	}{
+		{panic: "foo", want: nil},
+		{panic: 42, want: nil},
+		{panic: io.EOF, want: io.EOF},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			err := func() (err error) {
+				defer func() { err = FromRecover(recover()) }()
+				panic(tt.panic)
+			}()
+			panicErr := &panicError{}
+			if !errors.As(err, &panicErr) {
+				t.Fatalf("errors.As(err, &panicErr): must return true")
+			}
+			if got := panicErr.Unwrap(); got != tt.want {
+				t.Errorf("panicError.Unwrap(): got: %#v, want %#v", got, tt.want)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("errors.Is(FromRecover(%#v), err): must return true", tt.panic)
+			}
+		})
+	}
+}
+
+func TestPanicErrorNested(t *testing.T) {
+	recovered := func(v interface{}) (err error) {
+		defer func() { err = FromRecover(recover()) }()
+		panic(v)
+	}
+	inner := recovered("foo")
+	outer := recovered(inner)
+	if got, want := outer.Error(), "panic: foo"; got != want {
+		t.Errorf("FromRecover(panicErr).Error(): got: %q, want %q", got, want)
+	}
+	if !errors.Is(outer, inner) {
+		t.Errorf("errors.Is(FromRecover(panicErr), panicErr): must return true")
+	}
+	var pe PanicError
+	if !errors.As(outer, &pe) || pe.Panic() != inner {
+		t.Errorf("FromRecover(panicErr): must record the second panic")
+	}
+	withTrace := New("bar")
+	got := Sprint(recovered(withTrace))
+	want := Sprint(withTrace)[len("Error: bar\n"):]
+	if !strings.HasPrefix(got, "Error: panic: bar\n") || !strings.Contains(got, "Previous error: bar\n"+want) {
+		t.Errorf("Sprint(FromRecover(err)): %q must contain the stack trace of err %q", got, want)
+	}
+}
+
+func TestPanicErrorPanicType(t *testing.T) {
+	tests := []struct {
+		panic interface{}
+		want  string
+	}{
+		{panic: "foo", want: "string"},
+		{panic: 42, want: "int"},
+		{panic: io.EOF, want: "*errors.errorString"},
+		{panic: &ptrErr{msg: "foo"}, want: "*xerrors.ptrErr"},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			err := func() (err error) {
+				defer func() { err = FromRecover(recover()) }()
+				panic(tt.panic)
+			}()
+			var pe PanicError
+			if !errors.As(err, &pe) {
+				t.Fatalf("errors.As(err, &pe): must return true")
+			}
+			if got := pe.PanicType(); got != tt.want {
+				t.Errorf("PanicError.PanicType(): got: %q, want %q", got, tt.want)
+			}
+			if got := Sprint(err); !strings.HasSuffix(got, "\tpanic value of type "+tt.want+"\n") {
+				t.Errorf("Sprint(err): %q must contain the panic type", got)
+			}
+		})
+	}
+	if got := (&panicError{}).PanicType(); got != "nil" {
+		t.Errorf("panicError.PanicType(): got: %q, want %q", got, "nil")
+	}
+}
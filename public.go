@@ -0,0 +1,76 @@
+package xerrors
+
+import (
+	"fmt"
+)
+
+// WithPublicMessage attaches a public message to err. The public message is
+// intended to be shown to users, for example in API responses, while the
+// message returned by the Error method, along with other details, is intended
+// for internal logs. The public message can be retrieved using the
+// PublicMessage function. It does not change the message of err and does not
+// record a stack trace.
+//
+// If err is nil, then nil is returned.
+func WithPublicMessage(err error, public string) error {
+	if err == nil {
+		return nil
+	}
+	return &withPublicMessage{
+		err:    err,
+		public: public,
+	}
+}
+
+// PublicMessage returns the public message attached to err by the
+// WithPublicMessage function. If the error chain contains multiple public
+// messages, the outermost one is returned. Causes hidden by the
+// WithHiddenCause function are not checked. If err does not have a public
+// message, the result of the Error method is returned.
+//
+// If err is nil, an empty string is returned.
+func PublicMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+	for e := err; e != nil; {
+		if pe, ok := e.(*withPublicMessage); ok {
+			return pe.public
+		}
+		if he, ok := e.(*withHiddenCause); ok {
+			// The hidden cause is not a part of the message, so only the
+			// wrapping error is checked.
+			e = he.err
+			continue
+		}
+		if we, ok := e.(Wrapper); ok {
+			e = we.Unwrap()
+			continue
+		}
+		break
+	}
+	return err.Error()
+}
+
+// withPublicMessage adds a public message to an error.
+type withPublicMessage struct {
+	err    error
+	public string
+}
+
+// Error implements the error interface.
+func (e *withPublicMessage) Error() string {
+	return e.err.Error()
+}
+
+// Format implements the fmt.Formatter interface. It supports the same verbs
+// as the Format method of errors created by the WithWrapper function.
+func (e *withPublicMessage) Format(s fmt.State, verb rune) {
+	type _withPublicMessage withPublicMessage
+	formatError(s, verb, e, (*_withPublicMessage)(e))
+}
+
+// Unwrap implements the Wrapper interface.
+func (e *withPublicMessage) Unwrap() error {
+	return e.err
+}
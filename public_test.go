@@ -0,0 +1,47 @@
+package xerrors
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestWithPublicMessage(t *testing.T) {
+	tests := []struct {
+		err        error
+		want       string
+		wantPublic string
+		wantNil    bool
+	}{
+		{err: WithPublicMessage(nil, "public"), wantNil: true},
+		{err: io.EOF, want: "EOF", wantPublic: "EOF"},
+		{err: WithPublicMessage(io.EOF, "public"), want: "EOF", wantPublic: "public"},
+		{err: New("foo", WithPublicMessage(io.EOF, "public")), want: "foo: EOF", wantPublic: "public"},
+		{err: WithPublicMessage(New(WithPublicMessage(io.EOF, "inner")), "outer"), want: "EOF", wantPublic: "outer"},
+		{err: WithHiddenCause(WithPublicMessage(Message("foo"), "public"), io.EOF), want: "foo", wantPublic: "public"},
+		{err: WithHiddenCause(Message("foo"), WithPublicMessage(io.EOF, "public")), want: "foo", wantPublic: "foo"},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			if tt.wantNil {
+				if tt.err != nil {
+					t.Errorf("WithPublicMessage(nil, public): must return nil")
+				}
+				return
+			}
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("WithPublicMessage(err, public).Error(): got: %q, want: %q", got, tt.want)
+			}
+			if !errors.Is(tt.err, io.EOF) {
+				t.Errorf("errors.Is(WithPublicMessage(err, public), err): must return true")
+			}
+			if got := PublicMessage(tt.err); got != tt.wantPublic {
+				t.Errorf("PublicMessage(%#v): got: %q, want: %q", tt.err, got, tt.wantPublic)
+			}
+		})
+	}
+	if got := PublicMessage(nil); got != "" {
+		t.Errorf("PublicMessage(nil): got: %q, want an empty string", got)
+	}
+}
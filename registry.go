@@ -0,0 +1,42 @@
+package xerrors
+
+import (
+	"sync"
+)
+
+// Registry maps error messages to sentinel errors. It can be used to restore
+// the identity of an error that crossed a serialization boundary, when only
+// its message is known, so that it can be checked using the errors.Is
+// function.
+//
+// The zero value is ready to use. A Registry must not be copied after first
+// use. It is safe for concurrent use by multiple goroutines.
+type Registry struct {
+	mu   sync.RWMutex
+	errs map[string]error
+}
+
+// Register adds err to the registry. Errors are registered under their full
+// messages, not truncated by the SetMaxMessageLength function. If an error
+// with the same message has already been registered, it is replaced. Nil
+// errors are ignored.
+func (r *Registry) Register(err error) {
+	if err == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.errs == nil {
+		r.errs = make(map[string]error)
+	}
+	r.errs[fullMessage(err)] = err
+}
+
+// Resolve returns the registered error with the given message. If there is
+// no such error, (nil, false) is returned.
+func (r *Registry) Resolve(msg string) (error, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	err, ok := r.errs[msg]
+	return err, ok
+}
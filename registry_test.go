@@ -0,0 +1,39 @@
+package xerrors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	errFoo, errBar := Message("foo"), Message("bar")
+	r := &Registry{}
+	if _, ok := r.Resolve("foo"); ok {
+		t.Errorf("Registry.Resolve(\"foo\"): empty registry must not resolve errors")
+	}
+	r.Register(errFoo)
+	r.Register(errBar)
+	r.Register(nil)
+	tests := []struct {
+		msg    string
+		want   error
+		wantOk bool
+	}{
+		{msg: "foo", want: errFoo, wantOk: true},
+		{msg: "bar", want: errBar, wantOk: true},
+		{msg: "baz", want: nil, wantOk: false},
+		{msg: "", want: nil, wantOk: false},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			got, ok := r.Resolve(tt.msg)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("Registry.Resolve(%q): got: (%v, %v), want: (%v, %v)", tt.msg, got, ok, tt.want, tt.wantOk)
+			}
+			if tt.wantOk && !errors.Is(New("ctx", got), tt.want) {
+				t.Errorf("errors.Is(New(\"ctx\", Registry.Resolve(%q)), err): must return true", tt.msg)
+			}
+		})
+	}
+}
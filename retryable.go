@@ -0,0 +1,55 @@
+package xerrors
+
+import (
+	"fmt"
+)
+
+// WithRetryable marks err as retryable, which means that the operation that
+// failed may succeed if it is retried. The mark can be checked using the
+// IsRetryable function. It does not record a stack trace.
+//
+// If err is nil, then nil is returned.
+func WithRetryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &withRetryable{err: err}
+}
+
+// IsRetryable reports whether err, or any error it wraps, was marked as
+// retryable by the WithRetryable function.
+func IsRetryable(err error) bool {
+	for err != nil {
+		if _, ok := err.(*withRetryable); ok {
+			return true
+		}
+		if e, ok := err.(Wrapper); ok {
+			err = e.Unwrap()
+			continue
+		}
+		break
+	}
+	return false
+}
+
+// withRetryable marks an error as retryable.
+type withRetryable struct {
+	err error
+}
+
+// Error implements the error interface.
+func (e *withRetryable) Error() string {
+	return e.err.Error()
+}
+
+// Format implements the fmt.Formatter interface. It supports the same verbs
+// as the Format method of errors created by the WithWrapper function.
+func (e *withRetryable) Format(s fmt.State, verb rune) {
+	type _withRetryable withRetryable
+	formatError(s, verb, e, (*_withRetryable)(e))
+}
+
+// Unwrap implements the Wrapper interface.
+func (e *withRetryable) Unwrap() error {
+	return e.err
+}
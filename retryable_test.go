@@ -0,0 +1,43 @@
+package xerrors
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestWithRetryable(t *testing.T) {
+	tests := []struct {
+		err     error
+		want    string
+		wantRet bool
+		wantNil bool
+	}{
+		{err: WithRetryable(nil), wantNil: true},
+		{err: io.EOF, want: "EOF", wantRet: false},
+		{err: WithRetryable(io.EOF), want: "EOF", wantRet: true},
+		{err: WithRetryable(New("connection reset")), want: "connection reset", wantRet: true},
+		{err: New("foo", WithRetryable(io.EOF)), want: "foo: EOF", wantRet: true},
+		{err: WithWrapper(WithRetryable(Message("foo")), io.EOF), want: "foo: EOF", wantRet: false},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			if tt.wantNil {
+				if tt.err != nil {
+					t.Errorf("WithRetryable(nil): must return nil")
+				}
+				return
+			}
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("WithRetryable(err).Error(): got: %q, want: %q", got, tt.want)
+			}
+			if got := IsRetryable(tt.err); got != tt.wantRet {
+				t.Errorf("IsRetryable(%#v): got: %v, want: %v", tt.err, got, tt.wantRet)
+			}
+		})
+	}
+	if err := WithRetryable(io.EOF); !errors.Is(err, io.EOF) {
+		t.Errorf("errors.Is(WithRetryable(err), err): must return true")
+	}
+}
@@ -0,0 +1,96 @@
+package xerrors
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Severity is the severity level of an error.
+type Severity int
+
+// Severity levels, from the least to the most severe.
+const (
+	SeverityDebug Severity = iota
+	SeverityInfo
+	SeverityWarn
+	SeverityError
+	SeverityFatal
+)
+
+// String implements the fmt.Stringer interface.
+func (s Severity) String() string {
+	switch s {
+	case SeverityDebug:
+		return "debug"
+	case SeverityInfo:
+		return "info"
+	case SeverityWarn:
+		return "warn"
+	case SeverityError:
+		return "error"
+	case SeverityFatal:
+		return "fatal"
+	}
+	return "Severity(" + strconv.Itoa(int(s)) + ")"
+}
+
+// WithSeverity attaches a severity level to err. The level can be retrieved
+// using the SeverityOf function, and it is included in the error details.
+// It does not change the error message and does not record a stack trace.
+//
+// If err is nil, then nil is returned.
+func WithSeverity(err error, level Severity) error {
+	if err == nil {
+		return nil
+	}
+	return &withSeverity{
+		err:   err,
+		level: level,
+	}
+}
+
+// SeverityOf returns the severity level attached to err by the WithSeverity
+// function. If the error chain contains multiple levels, the outermost one
+// is returned. If err does not have a severity level, SeverityError is
+// returned.
+func SeverityOf(err error) Severity {
+	for err != nil {
+		if e, ok := err.(*withSeverity); ok {
+			return e.level
+		}
+		if e, ok := err.(Wrapper); ok {
+			err = e.Unwrap()
+			continue
+		}
+		break
+	}
+	return SeverityError
+}
+
+// withSeverity adds a severity level to an error.
+type withSeverity struct {
+	err   error
+	level Severity
+}
+
+// Error implements the error interface.
+func (e *withSeverity) Error() string {
+	return e.err.Error()
+}
+
+// ErrorDetails implements the DetailedError interface.
+func (e *withSeverity) ErrorDetails() string {
+	return indentString + "severity: " + e.level.String() + lineEnding
+}
+
+// Format implements the fmt.Formatter interface. It supports the same verbs
+// as the Format method of errors created by the WithWrapper function.
+func (e *withSeverity) Format(s fmt.State, verb rune) {
+	type _withSeverity withSeverity
+	formatError(s, verb, e, (*_withSeverity)(e))
+}
+
+// Unwrap implements the Wrapper interface.
+func (e *withSeverity) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,69 @@
+package xerrors
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestWithSeverity(t *testing.T) {
+	tests := []struct {
+		err       error
+		want      string
+		wantLevel Severity
+		wantNil   bool
+	}{
+		{err: WithSeverity(nil, SeverityWarn), wantNil: true},
+		{err: io.EOF, want: "EOF", wantLevel: SeverityError},
+		{err: WithSeverity(io.EOF, SeverityWarn), want: "EOF", wantLevel: SeverityWarn},
+		{err: New("foo", WithSeverity(io.EOF, SeverityDebug)), want: "foo: EOF", wantLevel: SeverityDebug},
+		{err: WithSeverity(New(WithSeverity(io.EOF, SeverityInfo)), SeverityFatal), want: "EOF", wantLevel: SeverityFatal},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			if tt.wantNil {
+				if tt.err != nil {
+					t.Errorf("WithSeverity(nil, level): must return nil")
+				}
+				return
+			}
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("WithSeverity(err, level).Error(): got: %q, want: %q", got, tt.want)
+			}
+			if !errors.Is(tt.err, io.EOF) {
+				t.Errorf("errors.Is(WithSeverity(err, level), err): must return true")
+			}
+			if got := SeverityOf(tt.err); got != tt.wantLevel {
+				t.Errorf("SeverityOf(%#v): got: %v, want: %v", tt.err, got, tt.wantLevel)
+			}
+		})
+	}
+	if got, want := Sprint(WithSeverity(Message("foo"), SeverityWarn)), "Error: foo\n\tseverity: warn\n"; got != want {
+		t.Errorf("Sprint(WithSeverity(err, SeverityWarn)): got: %q, want: %q", got, want)
+	}
+	errs := Append(WithSeverity(Message("a"), SeverityWarn), WithSeverity(Message("b"), SeverityFatal))
+	fatal := Filter(errs, func(err error) bool { return SeverityOf(err) == SeverityFatal })
+	if got, want := fatal.Error(), "b"; got != want {
+		t.Errorf("Filter(errs, fatal): got: %q, want: %q", got, want)
+	}
+}
+
+func TestSeverityString(t *testing.T) {
+	tests := []struct {
+		level Severity
+		want  string
+	}{
+		{level: SeverityDebug, want: "debug"},
+		{level: SeverityInfo, want: "info"},
+		{level: SeverityWarn, want: "warn"},
+		{level: SeverityError, want: "error"},
+		{level: SeverityFatal, want: "fatal"},
+		{level: Severity(42), want: "Severity(42)"},
+	}
+	for _, tt := range tests {
+		if got := tt.level.String(); got != tt.want {
+			t.Errorf("Severity(%d).String(): got: %q, want: %q", int(tt.level), got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+//go:build go1.21
+// +build go1.21
+
+package xerrors
+
+import (
+	"log/slog"
+)
+
+// LogValue implements the slog.LogValuer interface.
+func (e *withStackTrace) LogValue() slog.Value {
+	return logValue(e)
+}
+
+// LogValue implements the slog.LogValuer interface.
+func (e *withWrapper) LogValue() slog.Value {
+	return logValue(e)
+}
+
+// logValue returns a group with the error message and the first stack trace
+// found in the error chain.
+func logValue(err error) slog.Value {
+	attrs := []slog.Attr{slog.String("message", err.Error())}
+	if st := StackTrace(err); len(st) > 0 {
+		attrs = append(attrs, slog.String("stack", st.String()))
+	}
+	return slog.GroupValue(attrs...)
+}
@@ -0,0 +1,41 @@
+//go:build go1.21
+// +build go1.21
+
+package xerrors
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestLogValue(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{err: Message("foo"), want: `^level=INFO msg=test error=foo\n$`},
+		{err: WithWrapper(Message("foo"), Message("bar")), want: `^level=INFO msg=test error.message="foo: bar"\n$`},
+		{err: New("foo"), want: `^level=INFO msg=test error.message=foo error.stack="\\tat go-xerrors.TestLogValue \(.*\)\\n.*"\n$`},
+		{err: WithWrapper(Message("foo"), New("bar")), want: `^level=INFO msg=test error.message="foo: bar" error.stack="\\tat go-xerrors.TestLogValue \(.*\)\\n.*"\n$`},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			buf := &strings.Builder{}
+			logger := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{
+				ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+					if a.Key == slog.TimeKey && len(groups) == 0 {
+						return slog.Attr{}
+					}
+					return a
+				},
+			}))
+			logger.Info("test", slog.Any("error", tt.err))
+			if match, _ := regexp.MatchString(tt.want, buf.String()); !match {
+				t.Errorf("slog.Any(\"error\", %#v): %q does not match %q", tt.err, buf.String(), tt.want)
+			}
+		})
+	}
+}
@@ -1,14 +1,171 @@
 package xerrors
 
 import (
+	"encoding/binary"
 	"fmt"
+	"hash/fnv"
 	"io"
+	"io/ioutil"
+	"reflect"
 	"runtime"
 	"strconv"
 	"strings"
 )
 
-const stackTraceDepth = 32
+const defaultStackTraceDepth = 32
+
+var stackTraceDepth = defaultStackTraceDepth
+
+// SetStackTraceDepth sets the maximum number of stack frames recorded for
+// newly created errors. Values smaller than 1 are treated as 1. The default
+// depth is 32.
+//
+// This function is not thread-safe and should be called only once, during
+// the program initialization.
+func SetStackTraceDepth(n int) {
+	if n < 1 {
+		n = 1
+	}
+	stackTraceDepth = n
+}
+
+var maxRetainedFrames int
+
+// SetMaxRetainedFrames sets the maximum number of program counters retained
+// in stack traces of newly created errors. Stack traces are truncated at
+// capture time, after frames of this package are removed and frames of
+// packages set by the SetSkipPackages function are skipped, so that only the
+// innermost frames of the user code are kept. It can be used to reduce the
+// memory used by retained errors. If n is 0 or less, stack traces are not
+// truncated, which is the default.
+//
+// Unlike SetStackTraceDepth, it does not limit the number of frames that
+// are inspected when skipping frames of the packages set by SetSkipPackages.
+//
+// This function is not thread-safe and should be called only once, during
+// the program initialization.
+func SetMaxRetainedFrames(n int) {
+	maxRetainedFrames = n
+}
+
+var frameFilter func(Frame) bool
+
+// SetFrameFilter sets a function that decides which frames are included in
+// formatted stack traces. Frames for which fn returns false are omitted.
+// If fn is nil, all frames are included, which is the default.
+//
+// The filter only affects the output of the String and Format methods of
+// Callers and the ErrorDetails method of errors with a stack trace. It does
+// not change the frames returned by the Frames method.
+//
+// This function is not thread-safe and should be called only once, during
+// the program initialization.
+func SetFrameFilter(fn func(Frame) bool) {
+	frameFilter = fn
+}
+
+// ExcludeRuntimeFrames is a frame filter that can be used with the
+// SetFrameFilter function. It omits frames from the runtime and testing
+// packages.
+func ExcludeRuntimeFrames(f Frame) bool {
+	return !strings.HasPrefix(f.Function, "runtime.") && !strings.HasPrefix(f.Function, "testing.")
+}
+
+var skipPackages []string
+
+// SetSkipPackages sets a list of packages whose frames are skipped at the
+// beginning of recorded stack traces. It can be used to hide helper
+// functions, for example from a logging library, that create errors on
+// behalf of their callers, so that the first frame of the stack trace points
+// to the caller. Packages are specified by their import paths, and their
+// subpackages are skipped as well.
+//
+// Only leading frames are skipped. Frames of the listed packages that appear
+// further in the stack trace are recorded as usual.
+//
+// This function is not thread-safe and should be called only once, during
+// the program initialization.
+func SetSkipPackages(pkgs []string) {
+	skipPackages = pkgs
+}
+
+var trimBelow string
+
+// SetTrimBelow sets the name of a function below which frames are omitted
+// in formatted stack traces, as if the stack traces were trimmed using the
+// Callers.TrimBelow method. It can be used to hide frames of the runtime or
+// a framework, for example by using "main.main" or the name of an HTTP
+// handler. If fn is empty, stack traces are not trimmed, which is the
+// default.
+//
+// The option only affects the output of the String and Format methods of
+// Callers and the ErrorDetails method of errors with a stack trace. It does
+// not change the recorded program counters.
+//
+// This function is not thread-safe and should be called only once, during
+// the program initialization.
+func SetTrimBelow(fn string) {
+	trimBelow = fn
+}
+
+var showSource bool
+
+// SetShowSource enables or disables printing of source code lines in
+// formatted stack traces. When enabled, every frame is followed by the line
+// of the source file it points to, if the file can be read. It is intended
+// for debugging, because source files are usually not available where
+// programs are deployed. Source lines are not printed by default.
+//
+// This function is not thread-safe and should be called only once, during
+// the program initialization.
+func SetShowSource(enabled bool) {
+	showSource = enabled
+}
+
+var sourceRoot string
+
+// SetSourceRoot sets a path prefix that is removed from file names in
+// formatted stack frames. It can be used to print paths relative to the
+// project root instead of absolute paths from the build environment.
+// Files without the prefix are printed unchanged.
+//
+// The prefix only affects the output of the String and Format methods. The
+// File field of the Frame structure always contains the full path.
+//
+// This function is not thread-safe and should be called only once, during
+// the program initialization.
+func SetSourceRoot(prefix string) {
+	sourceRoot = prefix
+}
+
+// StackTraceOrder is the order in which frames of stack traces are printed.
+type StackTraceOrder int
+
+const (
+	// TopDown prints the innermost frame, usually the place where the error
+	// was created, first and the entry point of the goroutine last.
+	TopDown StackTraceOrder = iota
+
+	// BottomUp prints the entry point of the goroutine first and the
+	// innermost frame last.
+	BottomUp
+)
+
+var stackTraceOrder = TopDown
+
+// SetStackTraceOrder sets the order in which frames of stack traces are
+// printed. The default order is TopDown.
+//
+// The order only affects the output of the String and Format methods of
+// Callers and the ErrorDetails method of errors with a stack trace. It does
+// not change the order of the recorded program counters or the frames
+// returned by the Frames method.
+//
+// This function is not thread-safe and should be called only once, during
+// the program initialization.
+func SetStackTraceOrder(order StackTraceOrder) {
+	stackTraceOrder = order
+}
 
 // StackTrace returns a stack trace from given error or the first stack trace
 // from the wrapped errors.
@@ -26,6 +183,27 @@ func StackTrace(err error) Callers {
 	return nil
 }
 
+// Origin returns the first frame of the stack trace returned by the
+// StackTrace function, which is usually the place where the error was
+// created. If the error does not have a stack trace, then (Frame{}, false)
+// is returned.
+func Origin(err error) (Frame, bool) {
+	var frame Frame
+	ok := false
+	StackTrace(err).Each(func(f Frame) bool {
+		frame, ok = f, true
+		return false
+	})
+	return frame, ok
+}
+
+// StackFingerprint returns the fingerprint of the stack trace returned by
+// the StackTrace function. If the error does not have a stack trace, 0 is
+// returned. See Callers.Fingerprint for more details.
+func StackFingerprint(err error) uint64 {
+	return StackTrace(err).Fingerprint()
+}
+
 // WithStackTrace adds a stack trace to the error at the point it was called.
 // The skip argument is the number of stack frames to skip.
 //
@@ -37,16 +215,59 @@ func WithStackTrace(err error, skip int) error {
 	if err == nil {
 		return nil
 	}
-	return &withStackTrace{
-		err:   err,
-		stack: callers(skip + 1),
+	return newWithStackTrace(err, skip+1)
+}
+
+// WithStack adds a stack trace to the error at the point it was called,
+// unless the error already contains a stack trace, in which case err is
+// returned unchanged.
+//
+// If err is nil, then nil is returned.
+func WithStack(err error) error {
+	if err == nil {
+		return nil
+	}
+	if len(StackTrace(err)) > 0 {
+		return err
 	}
+	return newWithStackTrace(err, 1)
+}
+
+// EnsureStackTrace adds a stack trace to the error at the point it was
+// called, unless the error itself already provides a stack trace, in which
+// case err is returned unchanged.
+//
+// Unlike WithStack, it only checks the outermost error, so it still records
+// a new stack trace if a stack trace is present only in one of the wrapped
+// errors.
+//
+// If err is nil, then nil is returned.
+func EnsureStackTrace(err error) error {
+	if err == nil {
+		return nil
+	}
+	if e, ok := err.(StackTracer); ok && len(e.StackTrace()) > 0 {
+		return err
+	}
+	return newWithStackTrace(err, 1)
 }
 
 // withStackTrace adds a stack trace to en error.
 type withStackTrace struct {
 	err   error
 	stack Callers
+	goid  uint64
+}
+
+// newWithStackTrace wraps err with a stack trace recorded at the point
+// newWithStackTrace was called, after skipping skip frames, so that skip 1
+// skips the function that called it.
+func newWithStackTrace(err error, skip int) *withStackTrace {
+	return &withStackTrace{
+		err:   err,
+		stack: callers(skip + 1),
+		goid:  goroutineID(),
+	}
 }
 
 // Error implements the error interface.
@@ -54,9 +275,65 @@ func (e *withStackTrace) Error() string {
 	return e.err.Error()
 }
 
+// fullMessage returns the error message, without truncation.
+func (e *withStackTrace) fullMessage() string {
+	return fullMessage(e.err)
+}
+
 // ErrorDetails implements the DetailedError interface.
 func (e *withStackTrace) ErrorDetails() string {
-	return e.stack.String()
+	s := &strings.Builder{}
+	e.writeDetails(s, nil)
+	return s.String()
+}
+
+// writeDetails writes the error details to w. Frames at the end of the
+// stack trace that are identical to the frames at the end of prev are
+// replaced with a single line that contains the number of omitted frames.
+// Both stack traces are trimmed according to the SetTrimBelow setting
+// first, so frames that are not printed are not counted.
+func (e *withStackTrace) writeDetails(w io.Writer, prev Callers) {
+	if e.goid != 0 {
+		io.WriteString(w, indentString)
+		io.WriteString(w, "in goroutine ")
+		io.WriteString(w, strconv.FormatUint(e.goid, 10))
+		io.WriteString(w, lineEnding)
+	}
+	stack := e.stack
+	if trimBelow != "" {
+		stack = stack.TrimBelow(trimBelow)
+		prev = prev.TrimBelow(trimBelow)
+	}
+	n := 0
+	for n < len(stack) && n < len(prev) && stack[len(stack)-n-1] == prev[len(prev)-n-1] {
+		n++
+	}
+	if stackTraceOrder != BottomUp {
+		stack[:len(stack)-n].writeTrace(w)
+	}
+	if n > 0 {
+		io.WriteString(w, indentString)
+		io.WriteString(w, "... ")
+		io.WriteString(w, strconv.Itoa(len(stack[len(stack)-n:].Frames())))
+		io.WriteString(w, " frames identical to above")
+		io.WriteString(w, lineEnding)
+	}
+	if stackTraceOrder == BottomUp {
+		stack[:len(stack)-n].writeTrace(w)
+	}
+}
+
+// Format implements the fmt.Formatter interface.
+//
+// The verbs:
+//
+// 	%s	the error message
+// 	%v	same as %s, the plus flag prints the error with a stack trace,
+// 		in the same way as the Sprint function
+// 	%q	a double-quoted Go string with the error message
+func (e *withStackTrace) Format(s fmt.State, verb rune) {
+	type _withStackTrace withStackTrace
+	formatError(s, verb, e, (*_withStackTrace)(e))
 }
 
 // Unwrap implements the Wrapper interface.
@@ -98,7 +375,7 @@ func (f Frame) Format(s fmt.State, verb rune) {
 	case 's':
 		f.writeFrame(s)
 	case 'f':
-		io.WriteString(s, f.File)
+		io.WriteString(s, trimSourceRoot(f.File))
 	case 'd':
 		io.WriteString(s, strconv.Itoa(f.Line))
 	case 'n':
@@ -123,36 +400,116 @@ func (f Frame) Format(s fmt.State, verb rune) {
 }
 
 func (f Frame) writeFrame(w io.Writer) {
-	io.WriteString(w, "\tat ")
+	io.WriteString(w, indentString)
+	io.WriteString(w, "at ")
 	io.WriteString(w, shortname(f.Function))
 	io.WriteString(w, " (")
-	io.WriteString(w, f.File)
+	io.WriteString(w, trimSourceRoot(f.File))
 	io.WriteString(w, ":")
 	io.WriteString(w, strconv.Itoa(f.Line))
 	io.WriteString(w, ")")
 }
 
 // Callers is a list of program counters returned by the runtime.Callers.
+//
+// Stack traces recorded by this package do not contain frames of functions
+// from this package, such as closures used by the Guard function, so that
+// they contain only the user code.
 type Callers []uintptr
 
 // Frames returns a slice of structures with a function/file/line information.
 func (c Callers) Frames() []Frame {
-	r := make([]Frame, len(c))
+	if len(c) == 0 {
+		return []Frame{}
+	}
+	// The number of frames may differ from the number of program counters,
+	// for example, a single program counter may correspond to multiple
+	// frames if functions were inlined.
+	r := make([]Frame, 0, len(c))
+	c.Each(func(f Frame) bool {
+		r = append(r, f)
+		return true
+	})
+	return r
+}
+
+// RuntimeFrames returns the frames as returned by the runtime.CallersFrames
+// function. Unlike the Frames method, it keeps all the information provided
+// by the runtime, such as program counters and function entry addresses.
+func (c Callers) RuntimeFrames() []runtime.Frame {
+	if len(c) == 0 {
+		return []runtime.Frame{}
+	}
+	r := make([]runtime.Frame, 0, len(c))
 	f := runtime.CallersFrames(c)
-	n := 0
 	for {
 		frame, more := f.Next()
-		r[n] = Frame{
+		r = append(r, frame)
+		if !more {
+			break
+		}
+	}
+	return r
+}
+
+// Each calls fn for every frame, starting from the innermost one, until fn
+// returns false. Frames are resolved one by one, so it is cheaper than the
+// Frames method if only a few frames are needed.
+func (c Callers) Each(fn func(Frame) bool) {
+	if len(c) == 0 {
+		return
+	}
+	f := runtime.CallersFrames(c)
+	for {
+		frame, more := f.Next()
+		if !fn(Frame{
 			File:     frame.File,
 			Line:     frame.Line,
 			Function: frame.Function,
-		}
-		if !more {
+		}) || !more {
 			break
 		}
-		n++
 	}
-	return r
+}
+
+// TrimBelow returns the part of the stack trace that ends with the first
+// frame of the fn function, omitting frames of its callers. The function
+// name must include the package path, for example "main.main" or
+// "github.com/user/app.(*Server).ServeHTTP". If there is no such frame, c is
+// returned unchanged.
+func (c Callers) TrimBelow(fn string) Callers {
+	for i := range c {
+		f := runtime.CallersFrames(c[i : i+1])
+		for {
+			frame, more := f.Next()
+			if frame.Function == fn {
+				return c[:i+1]
+			}
+			if !more {
+				break
+			}
+		}
+	}
+	return c
+}
+
+// Fingerprint returns a hash of the program counters. Stack traces recorded
+// at the same place, and reached through the same call path, have the same
+// fingerprint, so it can be used to group errors by their origin. For an
+// empty stack trace, 0 is returned.
+//
+// Fingerprints are only comparable between the same builds of a program.
+func (c Callers) Fingerprint() uint64 {
+	if len(c) == 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	var b [8]byte
+	for _, pc := range c {
+		binary.LittleEndian.PutUint64(b[:], uint64(pc))
+		h.Write(b[:])
+	}
+	return h.Sum64()
 }
 
 // String implements the fmt.Stringer interface.
@@ -162,6 +519,25 @@ func (c Callers) String() string {
 	return s.String()
 }
 
+// Pprof returns the stack trace in the layout used by pprof text reports.
+// Every line contains a single frame in the "function file:line" format,
+// starting from the innermost one. Unlike the String method, it always uses
+// full function names and paths, and it ignores formatting options, such as
+// frame filters.
+func (c Callers) Pprof() string {
+	s := &strings.Builder{}
+	c.Each(func(f Frame) bool {
+		s.WriteString(f.Function)
+		s.WriteString(" ")
+		s.WriteString(f.File)
+		s.WriteString(":")
+		s.WriteString(strconv.Itoa(f.Line))
+		s.WriteString("\n")
+		return true
+	})
+	return s.String()
+}
+
 // Format implements the fmt.Formatter interface.
 //
 // The verbs:
@@ -189,17 +565,163 @@ func (c Callers) Format(s fmt.State, verb rune) {
 }
 
 func (c Callers) writeTrace(w io.Writer) {
+	if trimBelow != "" {
+		c = c.TrimBelow(trimBelow)
+	}
 	frames := c.Frames()
+	if stackTraceOrder == BottomUp {
+		for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+			frames[i], frames[j] = frames[j], frames[i]
+		}
+	}
+	// Lines of source files are cached, because stack traces usually
+	// contain many frames from the same files.
+	var files map[string][]string
+	if showSource {
+		files = map[string][]string{}
+	}
 	for _, frame := range frames {
+		if frameFilter != nil && !frameFilter(frame) {
+			continue
+		}
 		frame.writeFrame(w)
-		io.WriteString(w, "\n")
+		io.WriteString(w, lineEnding)
+		if showSource {
+			if src, ok := sourceLine(files, frame.File, frame.Line); ok {
+				io.WriteString(w, indentString)
+				io.WriteString(w, indentString)
+				io.WriteString(w, src)
+				io.WriteString(w, lineEnding)
+			}
+		}
 	}
 }
 
+// callers records the program counters of the current goroutine's stack.
+// Resolving them into frames is the expensive part, so it is deferred until
+// the Frames method is called. The counters are captured into a stack
+// allocated buffer and then copied into a slice of exactly the needed size,
+// to avoid retaining unused capacity for every created error.
+//
+// Program counters of functions from this package are removed before the
+// stack trace is truncated, so they are never retained.
 func callers(skip int) Callers {
-	b := make([]uintptr, stackTraceDepth)
-	l := runtime.Callers(skip+2, b[:])
-	return b[:l]
+	var a [defaultStackTraceDepth]uintptr
+	b := a[:]
+	if stackTraceDepth > len(a) {
+		b = make([]uintptr, stackTraceDepth)
+	}
+	l := runtime.Callers(skip+2, b[:stackTraceDepth])
+	b = removeOwnCallers(b[:l])
+	b = b[skippedCallers(b):]
+	if maxRetainedFrames > 0 && len(b) > maxRetainedFrames {
+		b = b[:maxRetainedFrames]
+	}
+	c := make(Callers, len(b))
+	copy(c, b)
+	return c
+}
+
+// ownPackagePrefix is the prefix of names of functions from this package.
+var ownPackagePrefix = reflect.TypeOf(Frame{}).PkgPath() + "."
+
+// removeOwnCallers removes program counters that belong to functions from
+// this package, in place, and returns the resulting slice. A program counter
+// of a function inlined into a function from another package is kept.
+func removeOwnCallers(pcs []uintptr) []uintptr {
+	n := 0
+	for i := range pcs {
+		if !isOwnCaller(pcs[i]) {
+			pcs[n] = pcs[i]
+			n++
+		}
+	}
+	return pcs[:n]
+}
+
+// isOwnCaller reports whether all frames of the program counter belong to
+// functions from this package. Functions defined in test files are not
+// considered to be part of this package, so that the tests of this package
+// can inspect recorded stack traces.
+func isOwnCaller(pc uintptr) bool {
+	// Most program counters belong to other packages, so the innermost
+	// function is checked first, which is cheaper than resolving frames.
+	// The program counter is a return address, so pc-1 is used to find the
+	// call instruction.
+	if fn := runtime.FuncForPC(pc - 1); fn == nil || !strings.HasPrefix(fn.Name(), ownPackagePrefix) {
+		return false
+	}
+	f := runtime.CallersFrames([]uintptr{pc})
+	for {
+		frame, more := f.Next()
+		if !strings.HasPrefix(frame.Function, ownPackagePrefix) || strings.HasSuffix(frame.File, "_test.go") {
+			return false
+		}
+		if !more {
+			return true
+		}
+	}
+}
+
+// skippedCallers returns the number of leading program counters that belong
+// to the packages set by the SetSkipPackages function. If all of them do,
+// 0 is returned, so that the stack trace is never empty.
+func skippedCallers(pcs []uintptr) int {
+	if len(skipPackages) == 0 {
+		return 0
+	}
+	for i := range pcs {
+		// The program counter is copied, so that pcs, which usually points
+		// to a stack allocated buffer, does not escape to the heap.
+		f := runtime.CallersFrames([]uintptr{pcs[i]})
+		for {
+			frame, more := f.Next()
+			if !inSkippedPackage(frame.Function) {
+				return i
+			}
+			if !more {
+				break
+			}
+		}
+	}
+	return 0
+}
+
+// inSkippedPackage reports whether the function belongs to one of the
+// packages set by the SetSkipPackages function.
+func inSkippedPackage(fn string) bool {
+	for _, pkg := range skipPackages {
+		if strings.HasPrefix(fn, pkg+".") || strings.HasPrefix(fn, pkg+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceLine returns the line with the given number from the source file,
+// without leading and trailing whitespace. If the file cannot be read or the
+// line does not exist, ("", false) is returned. The lines of read files are
+// stored in files, and files that cannot be read are stored as nil, so that
+// each file is read only once.
+func sourceLine(files map[string][]string, file string, line int) (string, bool) {
+	if line < 1 {
+		return "", false
+	}
+	lines, ok := files[file]
+	if !ok {
+		if b, err := ioutil.ReadFile(file); err == nil {
+			lines = strings.Split(string(b), "\n")
+		}
+		files[file] = lines
+	}
+	if line > len(lines) {
+		return "", false
+	}
+	return strings.TrimSpace(lines[line-1]), true
+}
+
+func trimSourceRoot(file string) string {
+	return strings.TrimPrefix(file, sourceRoot)
 }
 
 func shortname(name string) string {
@@ -0,0 +1,59 @@
+package xerrors_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mdobak/go-xerrors"
+)
+
+func TestStackTraceExcludesOwnFrames(t *testing.T) {
+	const pkg = "github.com/mdobak/go-xerrors."
+	g := &xerrors.ErrorGroup{}
+	g.Go(func() error { panic("foo") })
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "New", err: xerrors.New("foo")},
+		{name: "NewSkip", err: xerrors.NewSkip(0, "foo")},
+		{name: "Errorf", err: xerrors.Errorf("foo")},
+		{name: "WithStackTrace", err: xerrors.WithStackTrace(xerrors.Message("foo"), 0)},
+		{name: "WithStack", err: xerrors.WithStack(xerrors.Message("foo"))},
+		{name: "NewMulti", err: xerrors.NewMulti(xerrors.Message("foo"))},
+		{name: "Guard", err: xerrors.Guard(func() error { panic("foo") })()},
+		{name: "ErrorGroup", err: g.Wait()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := xerrors.StackTrace(tt.err)
+			if len(st) == 0 {
+				t.Fatalf("StackTrace(err): must not be empty")
+			}
+			for _, f := range st.RuntimeFrames() {
+				if strings.HasPrefix(f.Function, pkg) {
+					t.Errorf("StackTrace(err): frame %q must not belong to the xerrors package", f.Function)
+				}
+			}
+			if frame, _ := xerrors.Origin(tt.err); !strings.HasPrefix(frame.Function, "github.com/mdobak/go-xerrors_test.") {
+				t.Errorf("Origin(err): got: %q, want a function from the test", frame.Function)
+			}
+		})
+	}
+}
+
+func TestNewAllocs(t *testing.T) {
+	// The stack trace is captured into a stack allocated buffer, so New
+	// allocates only the message error, the stack trace wrapper and the
+	// slice of program counters.
+	if n := testing.AllocsPerRun(100, func() { _ = xerrors.New("foo") }); n > 3 {
+		t.Errorf("New(\"foo\"): got %v allocations, want at most 3", n)
+	}
+}
+
+func BenchmarkNewExternal(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = xerrors.New("foo")
+	}
+}
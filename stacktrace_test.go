@@ -6,6 +6,7 @@ import (
 	"io"
 	"reflect"
 	"regexp"
+	"strings"
 	"testing"
 )
 
@@ -126,3 +127,434 @@ func TestCallersFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestSetStackTraceDepth(t *testing.T) {
+	defer SetStackTraceDepth(defaultStackTraceDepth)
+
+	var recurse func(n int) error
+	recurse = func(n int) error {
+		if n == 0 {
+			return New("foo")
+		}
+		return recurse(n - 1)
+	}
+	countRecurse := func(err error) int {
+		c := 0
+		for _, f := range StackTrace(err).Frames() {
+			if shortname(f.Function) == "go-xerrors.TestSetStackTraceDepth.func1" {
+				c++
+			}
+		}
+		return c
+	}
+
+	if got := len(StackTrace(recurse(59))); got != defaultStackTraceDepth {
+		t.Errorf("StackTrace(err): got %d frames, want %d", got, defaultStackTraceDepth)
+	}
+	SetStackTraceDepth(64)
+	if got := countRecurse(recurse(59)); got != 60 {
+		t.Errorf("StackTrace(err).Frames(): got %d recursive frames, want 60", got)
+	}
+	SetStackTraceDepth(0)
+	if got := len(StackTrace(recurse(59))); got != 1 {
+		t.Errorf("StackTrace(err): got %d frames, want 1", got)
+	}
+}
+
+func TestSetMaxRetainedFrames(t *testing.T) {
+	defer SetMaxRetainedFrames(0)
+	defer SetSkipPackages(nil)
+
+	full := StackTrace(New("foo"))
+	SetMaxRetainedFrames(1)
+	st := StackTrace(New("foo"))
+	if len(st) != 1 || cap(st) != 1 {
+		t.Errorf("SetMaxRetainedFrames(1): got %d program counters, want 1", len(st))
+	}
+	if got, want := st.Frames()[0].Function, full.Frames()[0].Function; got != want {
+		t.Errorf("SetMaxRetainedFrames(1): got: %q, want the innermost frame %q", got, want)
+	}
+	SetSkipPackages([]string{"github.com/mdobak/go-xerrors"})
+	st = StackTrace(New("foo"))
+	if len(st) != 1 || st.Frames()[0].Function != "testing.tRunner" {
+		t.Errorf("SetMaxRetainedFrames(1): frames must be truncated after skipping packages")
+	}
+	SetSkipPackages(nil)
+	SetMaxRetainedFrames(0)
+	if st := StackTrace(New("foo")); len(st) != len(full) {
+		t.Errorf("SetMaxRetainedFrames(0): got %d program counters, want %d", len(st), len(full))
+	}
+}
+
+func TestSetFrameFilter(t *testing.T) {
+	defer SetFrameFilter(nil)
+
+	err := New("foo")
+	frames := StackTrace(err).Frames()
+	all := StackTrace(err).String()
+	if !strings.Contains(all, "testing.tRunner") {
+		t.Fatalf("StackTrace(err).String(): %q must contain testing.tRunner frame", all)
+	}
+	SetFrameFilter(ExcludeRuntimeFrames)
+	got := StackTrace(err).String()
+	if match, _ := regexp.MatchString(`^\tat go-xerrors.TestSetFrameFilter \(.*\)\n$`, got); !match {
+		t.Errorf("StackTrace(err).String(): %q must contain only the TestSetFrameFilter frame", got)
+	}
+	if len(StackTrace(err).Frames()) != len(frames) {
+		t.Errorf("StackTrace(err).Frames(): frame filter must not affect returned frames")
+	}
+}
+
+func TestSetSourceRoot(t *testing.T) {
+	defer SetSourceRoot("")
+
+	frame := Frame{
+		File:     "/home/user/app/file.go",
+		Line:     42,
+		Function: "package/function",
+	}
+	tests := []struct {
+		root   string
+		format string
+		want   string
+	}{
+		{root: "", format: "%s", want: "\tat function (/home/user/app/file.go:42)"},
+		{root: "/home/user/app/", format: "%s", want: "\tat function (file.go:42)"},
+		{root: "/home/user/app/", format: "%f", want: "file.go"},
+		{root: "/home/user/app/", format: "%+v", want: "{File:/home/user/app/file.go Line:42 Function:package/function}"},
+		{root: "/other/", format: "%s", want: "\tat function (/home/user/app/file.go:42)"},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			SetSourceRoot(tt.root)
+			if got := fmt.Sprintf(tt.format, frame); got != tt.want {
+				t.Errorf("fmt.Sprtinf(%q, %#v): got: %q, want: %q", tt.format, frame, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetStackTraceOrder(t *testing.T) {
+	defer SetStackTraceOrder(TopDown)
+
+	st := callers(0)
+	frames := st.Frames()
+	topDown := st.String()
+	SetStackTraceOrder(BottomUp)
+	bottomUp := st.String()
+	lines := strings.Split(strings.TrimSuffix(bottomUp, "\n"), "\n")
+	if len(lines) != len(frames) {
+		t.Fatalf("Callers.String(): got %d lines, want %d", len(lines), len(frames))
+	}
+	if want := frames[0].String(); lines[len(lines)-1] != want {
+		t.Errorf("Callers.String(): last line %q does not match %q", lines[len(lines)-1], want)
+	}
+	if bottomUp == topDown {
+		t.Errorf("Callers.String(): order must change the output")
+	}
+	if got := st.Frames(); !reflect.DeepEqual(got, frames) {
+		t.Errorf("Callers.Frames(): order must not affect the frames")
+	}
+}
+
+func TestSetSkipPackages(t *testing.T) {
+	defer SetSkipPackages(nil)
+
+	tests := []struct {
+		pkgs []string
+		want string
+	}{
+		{pkgs: nil, want: "github.com/mdobak/go-xerrors.TestSetSkipPackages"},
+		{pkgs: []string{"github.com/mdobak/go-xerr"}, want: "github.com/mdobak/go-xerrors.TestSetSkipPackages"},
+		{pkgs: []string{"github.com/mdobak/go-xerrors"}, want: "testing.tRunner"},
+		{pkgs: []string{"github.com/mdobak"}, want: "testing.tRunner"},
+		{pkgs: []string{"github.com/mdobak/go-xerrors", "testing", "runtime"}, want: "github.com/mdobak/go-xerrors.TestSetSkipPackages"},
+	}
+	for n, tt := range tests {
+		SetSkipPackages(tt.pkgs)
+		frame, _ := Origin(New("foo"))
+		if frame.Function != tt.want {
+			t.Errorf("case-%d: Origin(New(\"foo\")): got: %q, want: %q", n+1, frame.Function, tt.want)
+		}
+	}
+}
+
+func TestSetShowSource(t *testing.T) {
+	defer SetShowSource(false)
+
+	st := callers(0) // source line
+	if strings.Contains(st.String(), "// source line") {
+		t.Errorf("Callers.String(): source lines must not be printed by default")
+	}
+	SetShowSource(true)
+	want := "\tat go-xerrors.TestSetShowSource (" + st.Frames()[0].File
+	lines := strings.Split(st.String(), "\n")
+	if !strings.HasPrefix(lines[0], want) || lines[1] != "\t\tst := callers(0) // source line" {
+		t.Errorf("Callers.String(): the source line must be printed below the frame, got: %q", lines[:2])
+	}
+	files := map[string][]string{}
+	if _, ok := sourceLine(files, "/nonexistent/file.go", 1); ok {
+		t.Errorf("sourceLine(files, file, 1): must return false for missing files")
+	}
+	if _, ok := sourceLine(files, st.Frames()[0].File, 1<<20); ok {
+		t.Errorf("sourceLine(files, file, line): must return false for missing lines")
+	}
+	if _, ok := files["/nonexistent/file.go"]; !ok || len(files) != 2 {
+		t.Errorf("sourceLine(files, file, line): read files must be cached, got: %d files", len(files))
+	}
+	files["/nonexistent/file.go"] = []string{"\tcached line"}
+	if got, ok := sourceLine(files, "/nonexistent/file.go", 1); !ok || got != "cached line" {
+		t.Errorf("sourceLine(files, file, 1): got: (%q, %v), want: (\"cached line\", true)", got, ok)
+	}
+}
+
+func TestWithStack(t *testing.T) {
+	withTrace := New("foo")
+	tests := []struct {
+		err      error
+		want     string
+		wantSame bool
+	}{
+		{err: nil, want: ""},
+		{err: Message("foo"), want: "foo"},
+		{err: io.EOF, want: "EOF"},
+		{err: withTrace, want: "foo", wantSame: true},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			err := WithStack(tt.err)
+			if tt.err == nil {
+				if err != nil {
+					t.Errorf("WithStack(nil): must return nil")
+				}
+				return
+			}
+			if got := err.Error(); got != tt.want {
+				t.Errorf("WithStack(%#v).Error(): got: %q, want: %q", tt.err, got, tt.want)
+			}
+			if tt.wantSame && err != tt.err {
+				t.Errorf("WithStack(%#v): must return the same error if it already contains a stack trace", tt.err)
+			}
+			st := StackTrace(err)
+			if len(st) == 0 {
+				t.Fatalf("WithStack(%#v): returned error must contain a stack trace", tt.err)
+			}
+			if !tt.wantSame && shortname(st.Frames()[0].Function) != "go-xerrors.TestWithStack.func1" {
+				t.Errorf("WithStack(%#v): the first frame of stack trace must start at xerrors.TestWithStack.func1", tt.err)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("errors.Is(WithStack(%#v), err): must return true", tt.err)
+			}
+		})
+	}
+}
+
+func TestStackFingerprint(t *testing.T) {
+	var errs []error
+	for _, msg := range []string{"foo", "bar"} {
+		errs = append(errs, New(msg))
+	}
+	a, b := errs[0], errs[1]
+	c := New("foo")
+	if StackFingerprint(a) == 0 {
+		t.Errorf("StackFingerprint(err): must not return 0 for errors with a stack trace")
+	}
+	if StackFingerprint(a) != StackFingerprint(b) {
+		t.Errorf("StackFingerprint(err): errors created at the same place must have the same fingerprint")
+	}
+	if StackFingerprint(a) == StackFingerprint(c) {
+		t.Errorf("StackFingerprint(err): errors created at different places must have different fingerprints")
+	}
+	if StackFingerprint(Message("foo")) != 0 {
+		t.Errorf("StackFingerprint(err): must return 0 for errors without a stack trace")
+	}
+}
+
+func TestOrigin(t *testing.T) {
+	tests := []struct {
+		err      error
+		wantFunc string
+		wantOk   bool
+	}{
+		{err: nil, wantOk: false},
+		{err: Message("foo"), wantOk: false},
+		{err: New("foo"), wantFunc: "go-xerrors.TestOrigin", wantOk: true},
+		{err: WithWrapper(Message("foo"), New("bar")), wantFunc: "go-xerrors.TestOrigin", wantOk: true},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			got, ok := Origin(tt.err)
+			if ok != tt.wantOk {
+				t.Fatalf("Origin(%#v): got: %v, want %v", tt.err, ok, tt.wantOk)
+			}
+			if shortname(got.Function) != tt.wantFunc {
+				t.Errorf("Origin(%#v): got: %q, want %q", tt.err, shortname(got.Function), tt.wantFunc)
+			}
+		})
+	}
+}
+
+func TestCallersFramesEmpty(t *testing.T) {
+	for _, c := range []Callers{nil, {}, StackTrace(Message("foo"))} {
+		if frames := c.Frames(); frames == nil || len(frames) != 0 {
+			t.Errorf("Callers(%#v).Frames(): got: %#v, want an empty slice", c, frames)
+		}
+		if s := c.String(); s != "" {
+			t.Errorf("Callers(%#v).String(): got: %q, want an empty string", c, s)
+		}
+	}
+}
+
+func TestCallersEach(t *testing.T) {
+	st := callers(0)
+	frames := st.Frames()
+	var all []Frame
+	st.Each(func(f Frame) bool {
+		all = append(all, f)
+		return true
+	})
+	if !reflect.DeepEqual(all, frames) {
+		t.Errorf("Callers.Each(fn): frames do not match the Frames method")
+	}
+	var first []Frame
+	st.Each(func(f Frame) bool {
+		first = append(first, f)
+		return len(first) < 2
+	})
+	if !reflect.DeepEqual(first, frames[:2]) {
+		t.Errorf("Callers.Each(fn): must stop when fn returns false")
+	}
+	Callers(nil).Each(func(f Frame) bool {
+		t.Errorf("Callers.Each(fn): fn must not be called for an empty stack trace")
+		return true
+	})
+}
+
+func TestCallersTrimBelow(t *testing.T) {
+	defer SetTrimBelow("")
+
+	st := callers(0)
+	frames := st.Frames()
+	tests := []struct {
+		fn   string
+		want int
+	}{
+		{fn: "github.com/mdobak/go-xerrors.TestCallersTrimBelow", want: 1},
+		{fn: "testing.tRunner", want: 2},
+		{fn: "TestCallersTrimBelow", want: len(frames)},
+		{fn: "", want: len(frames)},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			got := st.TrimBelow(tt.fn).Frames()
+			if !reflect.DeepEqual(got, frames[:tt.want]) {
+				t.Errorf("Callers.TrimBelow(%q): got: %v, want: %v", tt.fn, got, frames[:tt.want])
+			}
+			SetTrimBelow(tt.fn)
+			if got, want := st.String(), Callers(st[:tt.want]).String(); tt.fn != "" && got != want {
+				t.Errorf("SetTrimBelow(%q): got: %q, want: %q", tt.fn, got, want)
+			}
+		})
+	}
+}
+
+func TestCallersPprof(t *testing.T) {
+	defer SetFrameFilter(nil)
+
+	SetFrameFilter(ExcludeRuntimeFrames)
+	st := callers(0)
+	got := strings.Split(strings.TrimSuffix(st.Pprof(), "\n"), "\n")
+	frames := st.Frames()
+	if len(got) != len(frames) {
+		t.Fatalf("Callers.Pprof(): got %d lines, want %d", len(got), len(frames))
+	}
+	for i, f := range frames {
+		if want := fmt.Sprintf("%s %s:%d", f.Function, f.File, f.Line); got[i] != want {
+			t.Errorf("Callers.Pprof(): line %d: got: %q, want: %q", i+1, got[i], want)
+		}
+	}
+	if got := Callers(nil).Pprof(); got != "" {
+		t.Errorf("Callers(nil).Pprof(): got: %q, want an empty string", got)
+	}
+}
+
+func TestCallersRuntimeFrames(t *testing.T) {
+	st := callers(0)
+	frames := st.Frames()
+	got := st.RuntimeFrames()
+	if len(got) != len(frames) {
+		t.Fatalf("Callers.RuntimeFrames(): got %d frames, want %d", len(got), len(frames))
+	}
+	for i, f := range got {
+		if f.Function != frames[i].Function || f.File != frames[i].File || f.Line != frames[i].Line {
+			t.Errorf("Callers.RuntimeFrames(): frame %d does not match %v", i, frames[i])
+		}
+		if f.PC == 0 || f.Entry == 0 {
+			t.Errorf("Callers.RuntimeFrames(): frame %d must contain the program counter and entry address", i)
+		}
+	}
+	if got := Callers(nil).RuntimeFrames(); got == nil || len(got) != 0 {
+		t.Errorf("Callers(nil).RuntimeFrames(): must return an empty slice")
+	}
+}
+
+func TestCallersFramesFullBuffer(t *testing.T) {
+	var recurse func(n int) Callers
+	recurse = func(n int) Callers {
+		if n == 0 {
+			return callers(0)
+		}
+		return recurse(n - 1)
+	}
+	c := recurse(stackTraceDepth * 2)
+	if len(c) != stackTraceDepth {
+		t.Fatalf("callers(0): got %d program counters, want %d", len(c), stackTraceDepth)
+	}
+	frames := c.Frames()
+	if len(frames) < len(c) {
+		t.Errorf("Callers.Frames(): got %d frames, want at least %d", len(frames), len(c))
+	}
+	for n, f := range frames {
+		if f == (Frame{}) {
+			t.Errorf("Callers.Frames(): frame %d is empty", n)
+		}
+		if shortname(f.Function) != "go-xerrors.TestCallersFramesFullBuffer.func1" {
+			t.Errorf("Callers.Frames(): frame %d: got %q, want go-xerrors.TestCallersFramesFullBuffer.func1", n, f.Function)
+		}
+	}
+}
+
+func TestEnsureStackTrace(t *testing.T) {
+	withTrace := New("foo")
+	wrapped := WithWrapper(Message("bar"), withTrace)
+	tests := []struct {
+		err      error
+		wantSame bool
+	}{
+		{err: nil},
+		{err: io.EOF, wantSame: false},
+		{err: withTrace, wantSame: true},
+		{err: wrapped, wantSame: false},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			err := EnsureStackTrace(tt.err)
+			if tt.err == nil {
+				if err != nil {
+					t.Errorf("EnsureStackTrace(nil): must return nil")
+				}
+				return
+			}
+			if (err == tt.err) != tt.wantSame {
+				t.Errorf("EnsureStackTrace(%#v): returned the same error: %v, want %v", tt.err, err == tt.err, tt.wantSame)
+			}
+			st := StackTrace(err)
+			if !tt.wantSame && (len(st) == 0 || shortname(st.Frames()[0].Function) != "go-xerrors.TestEnsureStackTrace.func1") {
+				t.Errorf("EnsureStackTrace(%#v): the first frame of stack trace must start at xerrors.TestEnsureStackTrace.func1", tt.err)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("errors.Is(EnsureStackTrace(%#v), err): must return true", tt.err)
+			}
+		})
+	}
+}
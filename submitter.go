@@ -0,0 +1,72 @@
+package xerrors
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CaptureHere records a stack trace at the point it was called. It can be
+// used with the WithSubmitterStack function.
+func CaptureHere() Callers {
+	return callers(1)
+}
+
+// WithSubmitterStack attaches the stack trace of the place where the work
+// that failed with err was submitted, for example to a worker pool, and
+// returns the resulting error. The stack trace should be recorded at the
+// submission time using the CaptureHere function. Stack traces of errors
+// created by workers point to the worker goroutine, so the submitter stack
+// trace helps to find the code that requested the work.
+//
+// The submitter stack trace is included in the error details, below the
+// stack trace of err. It is not returned by the StackTrace function.
+//
+// If err is nil, then nil is returned. If submitted is empty, then err is
+// returned unchanged.
+func WithSubmitterStack(err error, submitted Callers) error {
+	if err == nil {
+		return nil
+	}
+	if len(submitted) == 0 {
+		return err
+	}
+	return &withSubmitterStack{
+		err:   err,
+		stack: submitted,
+	}
+}
+
+// withSubmitterStack adds the stack trace of the place where the work that
+// failed was submitted to an error.
+type withSubmitterStack struct {
+	err   error
+	stack Callers
+}
+
+// Error implements the error interface.
+func (e *withSubmitterStack) Error() string {
+	return e.err.Error()
+}
+
+// ErrorDetails implements the DetailedError interface.
+func (e *withSubmitterStack) ErrorDetails() string {
+	s := &strings.Builder{}
+	io.WriteString(s, indentString)
+	io.WriteString(s, "submitted from:")
+	io.WriteString(s, lineEnding)
+	e.stack.writeTrace(s)
+	return s.String()
+}
+
+// Format implements the fmt.Formatter interface. It supports the same verbs
+// as the Format method of errors created by the WithWrapper function.
+func (e *withSubmitterStack) Format(s fmt.State, verb rune) {
+	type _withSubmitterStack withSubmitterStack
+	formatError(s, verb, e, (*_withSubmitterStack)(e))
+}
+
+// Unwrap implements the Wrapper interface.
+func (e *withSubmitterStack) Unwrap() error {
+	return e.err
+}
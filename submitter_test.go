@@ -0,0 +1,37 @@
+package xerrors
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"testing"
+)
+
+func TestWithSubmitterStack(t *testing.T) {
+	if err := WithSubmitterStack(nil, CaptureHere()); err != nil {
+		t.Errorf("WithSubmitterStack(nil, st): must return nil")
+	}
+	if err := WithSubmitterStack(io.EOF, nil); err != io.EOF {
+		t.Errorf("WithSubmitterStack(err, nil): must return err")
+	}
+	submitted := CaptureHere()
+	if frame := submitted.Frames()[0]; frame.Function != "github.com/mdobak/go-xerrors.TestWithSubmitterStack" {
+		t.Errorf("CaptureHere(): the first frame must be the caller, got: %q", frame.Function)
+	}
+	ch := make(chan error)
+	go func() {
+		ch <- WithSubmitterStack(New("foo", io.EOF), submitted)
+	}()
+	err := <-ch
+	if got, want := err.Error(), "foo: EOF"; got != want {
+		t.Errorf("WithSubmitterStack(err, st).Error(): got: %q, want: %q", got, want)
+	}
+	if !errors.Is(err, io.EOF) {
+		t.Errorf("errors.Is(WithSubmitterStack(err, st), err): must return true")
+	}
+	want := `^Error: foo: EOF\n\tat go-xerrors.TestWithSubmitterStack.func1 \(.*\)\n(\tat .*\n)*` +
+		`\tsubmitted from:\n\tat go-xerrors.TestWithSubmitterStack \(.*\)\n(\tat .*\n)*$`
+	if got := Sprint(err); !regexp.MustCompile(want).MatchString(got) {
+		t.Errorf("Sprint(WithSubmitterStack(err, st)): %q does not match %q", got, want)
+	}
+}
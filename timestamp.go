@@ -0,0 +1,68 @@
+package xerrors
+
+import (
+	"fmt"
+	"time"
+)
+
+// WithTimestamp records the current time on err. The time can be retrieved
+// using the Timestamp function, and it is included in the error details.
+// It does not record a stack trace.
+//
+// If err is nil, then nil is returned.
+func WithTimestamp(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &withTimestamp{
+		err:  err,
+		time: time.Now(),
+	}
+}
+
+// Timestamp returns the time recorded on err by the WithTimestamp function.
+// If the error chain contains multiple timestamps, the outermost one is
+// returned.
+//
+// If err does not have a timestamp, then (time.Time{}, false) is returned.
+func Timestamp(err error) (time.Time, bool) {
+	for err != nil {
+		if e, ok := err.(*withTimestamp); ok {
+			return e.time, true
+		}
+		if e, ok := err.(Wrapper); ok {
+			err = e.Unwrap()
+			continue
+		}
+		break
+	}
+	return time.Time{}, false
+}
+
+// withTimestamp adds a timestamp to an error.
+type withTimestamp struct {
+	err  error
+	time time.Time
+}
+
+// Error implements the error interface.
+func (e *withTimestamp) Error() string {
+	return e.err.Error()
+}
+
+// ErrorDetails implements the DetailedError interface.
+func (e *withTimestamp) ErrorDetails() string {
+	return indentString + "created at " + e.time.Format(time.RFC3339) + lineEnding
+}
+
+// Format implements the fmt.Formatter interface. It supports the same verbs
+// as the Format method of errors created by the WithWrapper function.
+func (e *withTimestamp) Format(s fmt.State, verb rune) {
+	type _withTimestamp withTimestamp
+	formatError(s, verb, e, (*_withTimestamp)(e))
+}
+
+// Unwrap implements the Wrapper interface.
+func (e *withTimestamp) Unwrap() error {
+	return e.err
+}
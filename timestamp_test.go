@@ -0,0 +1,36 @@
+package xerrors
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestWithTimestamp(t *testing.T) {
+	if WithTimestamp(nil) != nil {
+		t.Errorf("WithTimestamp(nil): must return nil")
+	}
+	if _, ok := Timestamp(io.EOF); ok {
+		t.Errorf("Timestamp(io.EOF): must return false")
+	}
+
+	before := time.Now()
+	err := New("foo", WithTimestamp(io.EOF))
+	after := time.Now()
+	if got := err.Error(); got != "foo: EOF" {
+		t.Errorf("WithTimestamp(err).Error(): got: %q, want %q", got, "foo: EOF")
+	}
+	if !errors.Is(err, io.EOF) {
+		t.Errorf("errors.Is(WithTimestamp(err), err): must return true")
+	}
+	ts, ok := Timestamp(err)
+	if !ok || ts.Before(before) || ts.After(after) {
+		t.Errorf("Timestamp(err): got: (%v, %v), want time between %v and %v", ts, ok, before, after)
+	}
+	want := `^Error: EOF\n\tcreated at [0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9:]{8}(Z|[+-][0-9:]{5})\n$`
+	if got := Sprint(WithTimestamp(io.EOF)); !regexp.MustCompile(want).MatchString(got) {
+		t.Errorf("Sprint(WithTimestamp(err)): %q does not match %q", got, want)
+	}
+}
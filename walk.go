@@ -0,0 +1,61 @@
+package xerrors
+
+// Walk traverses the tree of errors rooted at err in depth-first order and
+// calls visit for every error, with the depth of the error in the tree. The
+// root error has depth 0. If visit returns false, the errors wrapped by the
+// visited error are skipped, but the traversal continues with its siblings.
+//
+// The children of an error are obtained using the Unwrap() error and
+// Unwrap() []error methods, and the Errors method of the MultiError
+// interface. For errors created by the WithWrapper function, both the
+// wrapper and the wrapped error are visited. For errors created by the
+// WithHiddenCause function, both the error and the hidden cause are visited.
+//
+// If err is nil, visit is not called.
+func Walk(err error, visit func(err error, depth int) bool) {
+	walk(err, 0, visit)
+}
+
+// FindFirst returns the first error in the tree of errors rooted at err for
+// which pred returns true, in the order used by the Walk function. If there
+// is no such error, nil is returned.
+func FindFirst(err error, pred func(error) bool) error {
+	var found error
+	Walk(err, func(e error, _ int) bool {
+		if found != nil {
+			return false
+		}
+		if pred(e) {
+			found = e
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+func walk(err error, depth int, visit func(err error, depth int) bool) {
+	if err == nil || !visit(err, depth) {
+		return
+	}
+	for _, e := range children(err) {
+		walk(e, depth+1, visit)
+	}
+}
+
+// children returns the errors directly wrapped by err.
+func children(err error) []error {
+	switch e := err.(type) {
+	case *withWrapper:
+		return []error{e.wrapper, e.err}
+	case *withHiddenCause:
+		return []error{e.err, e.cause}
+	case MultiError:
+		return e.Errors()
+	case interface{ Unwrap() []error }:
+		return e.Unwrap()
+	case Wrapper:
+		return []error{e.Unwrap()}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package xerrors
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	a, b, c := Message("a"), Message("b"), Message("c")
+	ab := WithWrapper(a, b)
+	abc := Append(ab, c)
+	st := New(abc)
+	hidden := WithHiddenCause(a, io.EOF)
+	type node struct {
+		err   error
+		depth int
+	}
+	tests := []struct {
+		err  error
+		skip error
+		want []node
+	}{
+		{err: nil, want: nil},
+		{err: a, want: []node{{a, 0}}},
+		{err: ab, want: []node{{ab, 0}, {a, 1}, {b, 1}}},
+		{err: st, want: []node{{st, 0}, {abc, 1}, {ab, 2}, {a, 3}, {b, 3}, {c, 2}}},
+		{err: st, skip: ab, want: []node{{st, 0}, {abc, 1}, {ab, 2}, {c, 2}}},
+		{err: hidden, want: []node{{hidden, 0}, {a, 1}, {io.EOF, 1}}},
+		{err: fmt.Errorf("%w", io.EOF), want: []node{{fmt.Errorf("%w", io.EOF), 0}, {io.EOF, 1}}},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			var got []node
+			Walk(tt.err, func(err error, depth int) bool {
+				got = append(got, node{err, depth})
+				return err != tt.skip
+			})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Walk(%#v, visit): got: %v, want: %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindFirst(t *testing.T) {
+	a, b := Message("a"), Message("b")
+	coded := WithCode(b, 42)
+	hasCode := func(err error) bool {
+		_, ok := err.(*withCode)
+		return ok
+	}
+	tests := []struct {
+		err  error
+		pred func(error) bool
+		want error
+	}{
+		{err: nil, pred: hasCode, want: nil},
+		{err: a, pred: hasCode, want: nil},
+		{err: New("foo", coded), pred: hasCode, want: coded},
+		{err: Append(a, New(coded)), pred: hasCode, want: coded},
+		{err: Append(a, b), pred: func(err error) bool { return err.Error() == "b" }, want: b},
+		{err: WithWrapper(coded, WithCode(a, 1)), pred: hasCode, want: coded},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			if got := FindFirst(tt.err, tt.pred); got != tt.want {
+				t.Errorf("FindFirst(%#v, pred): got: %#v, want: %#v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,8 @@ package xerrors
 
 import (
 	"errors"
+	"fmt"
+	"runtime"
 	"strings"
 )
 
@@ -15,6 +17,7 @@ import (
 //
 // If wrapper is nil, then err is returned.
 // If err is nil, then nil is returned.
+// If the depth limit set by SetMaxWrapDepth is reached, then err is returned.
 func WithWrapper(wrapper error, err error) error {
 	if err == nil {
 		return nil
@@ -22,12 +25,85 @@ func WithWrapper(wrapper error, err error) error {
 	if wrapper == nil {
 		return err
 	}
+	if maxWrapDepth > 0 && Depth(err) >= maxWrapDepth {
+		return err
+	}
 	return &withWrapper{
 		wrapper: wrapper,
 		err:     err,
 	}
 }
 
+// WithHiddenCause wraps err with cause, without including the message of
+// cause in the message of the returned error. It can be used to replace an
+// internal error with a message that is safe to show to users, while keeping
+// the internal error available for the errors.Is and errors.As functions.
+//
+// The Unwrap method returns cause, but errors.Is and errors.As work with
+// both of the errors. The message of cause is included in the error
+// details.
+//
+// If cause is nil, then err is returned.
+// If err is nil, then nil is returned.
+func WithHiddenCause(err error, cause error) error {
+	if err == nil {
+		return nil
+	}
+	if cause == nil {
+		return err
+	}
+	return &withHiddenCause{
+		err:   err,
+		cause: cause,
+	}
+}
+
+// WithCaller wraps err with a message that contains the name of the function
+// from which WithCaller was called, for example "pkg.Function: err". It does
+// not record a stack trace.
+//
+// If err is nil, then nil is returned.
+func WithCaller(err error) error {
+	if err == nil {
+		return nil
+	}
+	var pc [1]uintptr
+	if runtime.Callers(2, pc[:]) == 0 {
+		return err
+	}
+	frame, _ := runtime.CallersFrames(pc[:]).Next()
+	return &withWrapper{
+		wrapper: &messageError{msg: shortname(frame.Function)},
+		err:     err,
+	}
+}
+
+// Causes returns the errors that make up the given error chain, starting
+// from the outermost one. For errors created by the WithWrapper function,
+// both the wrapper and the wrapped error are included. Errors that only add
+// details without changing the message of the error they wrap, such as
+// stack traces or error codes, are skipped, and the innermost error is
+// always the last one.
+//
+// If err is nil, nil is returned.
+func Causes(err error) []error {
+	var errs []error
+	for err != nil {
+		if e, ok := err.(*withWrapper); ok {
+			errs = append(errs, Causes(e.wrapper)...)
+			err = e.err
+			continue
+		}
+		if u := unwrapAnnotation(err); u != nil {
+			err = u
+			continue
+		}
+		errs = append(errs, err)
+		err = nil
+	}
+	return errs
+}
+
 // withWrapper wraps an error with another error.
 type withWrapper struct {
 	wrapper error
@@ -36,13 +112,54 @@ type withWrapper struct {
 
 // Error implements the error interface.
 func (e *withWrapper) Error() string {
+	return truncateMessage(e.fullMessage())
+}
+
+// fullMessage returns the error message, without truncation.
+func (e *withWrapper) fullMessage() string {
 	s := &strings.Builder{}
-	s.WriteString(e.wrapper.Error())
-	s.WriteString(": ")
-	s.WriteString(e.err.Error())
+	e.writeMessage(s)
 	return s.String()
 }
 
+// writeMessage writes the full error message to s. Messages of nested
+// wrappers are written directly to s, so building the message of a deep
+// chain of wrappers does not build the messages of all wrapped errors.
+func (e *withWrapper) writeMessage(s *strings.Builder) {
+	writeMessage(s, e.wrapper)
+	s.WriteString(": ")
+	writeMessage(s, e.err)
+}
+
+// writeMessage writes the full message of err to s.
+func writeMessage(s *strings.Builder, err error) {
+	for {
+		switch e := err.(type) {
+		case *withWrapper:
+			e.writeMessage(s)
+			return
+		case *withStackTrace:
+			err = e.err
+			continue
+		}
+		s.WriteString(fullMessage(err))
+		return
+	}
+}
+
+// Format implements the fmt.Formatter interface.
+//
+// The verbs:
+//
+// 	%s	the error message
+// 	%v	same as %s, the plus flag prints the error with stack traces of
+// 		the wrapped errors, in the same way as the Sprint function
+// 	%q	a double-quoted Go string with the error message
+func (e *withWrapper) Format(s fmt.State, verb rune) {
+	type _withWrapper withWrapper
+	formatError(s, verb, e, (*_withWrapper)(e))
+}
+
 // Unwrap implements the Wrapper interface.
 func (e *withWrapper) Unwrap() error {
 	return e.err
@@ -55,3 +172,40 @@ func (e *withWrapper) As(target interface{}) bool {
 func (e *withWrapper) Is(target error) bool {
 	return errors.Is(e.wrapper, target) || errors.Is(e.err, target)
 }
+
+// withHiddenCause wraps an error with a cause that is not included in the
+// error message.
+type withHiddenCause struct {
+	err   error
+	cause error
+}
+
+// Error implements the error interface.
+func (e *withHiddenCause) Error() string {
+	return e.err.Error()
+}
+
+// ErrorDetails implements the DetailedError interface.
+func (e *withHiddenCause) ErrorDetails() string {
+	return indentString + "caused by: " + e.cause.Error() + lineEnding
+}
+
+// Format implements the fmt.Formatter interface. It supports the same verbs
+// as the Format method of errors created by the WithWrapper function.
+func (e *withHiddenCause) Format(s fmt.State, verb rune) {
+	type _withHiddenCause withHiddenCause
+	formatError(s, verb, e, (*_withHiddenCause)(e))
+}
+
+// Unwrap implements the Wrapper interface.
+func (e *withHiddenCause) Unwrap() error {
+	return e.cause
+}
+
+func (e *withHiddenCause) As(target interface{}) bool {
+	return errors.As(e.err, target) || errors.As(e.cause, target)
+}
+
+func (e *withHiddenCause) Is(target error) bool {
+	return errors.Is(e.err, target) || errors.Is(e.cause, target)
+}
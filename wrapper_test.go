@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -51,3 +52,124 @@ func TestWrap(t *testing.T) {
 		})
 	}
 }
+
+func TestWrapMessage(t *testing.T) {
+	defer SetMultiErrorPrefix("the following errors occurred: ")
+	err1 := WithWrapper(Message("wrapper"), Append(Message("foo"), Message("bar")))
+	err2 := WithWrapper(Message("wrapper"), Append(Message("foo"), Message("bar")))
+	want := err1.Error()
+	if !reflect.DeepEqual(err1, err2) {
+		t.Errorf("reflect.DeepEqual(err1, err2): errors must be equal after calling the Error method")
+	}
+	if got, want := fmt.Sprintf("%#v", err1), "&xerrors._withWrapper{wrapper:"; !strings.HasPrefix(got, want) || strings.Contains(got, "sync.Once") {
+		t.Errorf("fmt.Sprintf(\"%%#v\", err1): got: %q, want prefix: %q", got, want)
+	}
+	SetMultiErrorPrefix("errors: ")
+	if got := err1.Error(); got == want {
+		t.Errorf("WithWrapper(...).Error(): message must reflect the changed prefix, got: %q", got)
+	}
+}
+
+func TestWithHiddenCause(t *testing.T) {
+	errPublic := Message("internal error")
+	tests := []struct {
+		err     error
+		cause   error
+		want    string
+		wantNil bool
+	}{
+		{err: errPublic, cause: io.EOF, want: "internal error"},
+		{err: New(errPublic), cause: New("db", io.EOF), want: "internal error"},
+		{err: errPublic, cause: nil, want: "internal error"},
+		{err: nil, cause: io.EOF, wantNil: true},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			got := WithHiddenCause(tt.err, tt.cause)
+			if tt.wantNil {
+				if got != nil {
+					t.Errorf("WithHiddenCause(%#v, %#v): expected nil", tt.err, tt.cause)
+				}
+				return
+			}
+			if got.Error() != tt.want {
+				t.Errorf("WithHiddenCause(%#v, %#v): got: %q, want %q", tt.err, tt.cause, got, tt.want)
+			}
+			if !errors.Is(got, errPublic) {
+				t.Errorf("WithHiddenCause(%#v, %#v): errors.Is must return true for err", tt.err, tt.cause)
+			}
+			if tt.cause == nil {
+				return
+			}
+			if !errors.Is(got, io.EOF) {
+				t.Errorf("WithHiddenCause(%#v, %#v): errors.Is must return true for cause", tt.err, tt.cause)
+			}
+			if errors.Unwrap(got) != tt.cause {
+				t.Errorf("WithHiddenCause(%#v, %#v): Unwrap must return cause", tt.err, tt.cause)
+			}
+			if d := got.(DetailedError).ErrorDetails(); d != "\tcaused by: "+tt.cause.Error()+"\n" {
+				t.Errorf("WithHiddenCause(%#v, %#v): invalid error details: %q", tt.err, tt.cause, d)
+			}
+		})
+	}
+}
+
+func TestCauses(t *testing.T) {
+	a, b, c := Message("a"), Message("b"), Message("c")
+	tests := []struct {
+		err  error
+		want []error
+	}{
+		{err: nil, want: nil},
+		{err: a, want: []error{a}},
+		{err: New(a), want: []error{a}},
+		{err: WithWrapper(a, b), want: []error{a, b}},
+		{err: WithWrapper(a, WithWrapper(b, c)), want: []error{a, b, c}},
+		{err: New(a, b, c), want: []error{a, b, c}},
+		{err: WithWrapper(New(a), New(b)), want: []error{a, b}},
+		{err: WithCode(New(a, b), 1), want: []error{a, b}},
+		{err: WithSeverity(WithWrapper(a, WithRetryable(b)), SeverityWarn), want: []error{a, b}},
+		{err: WithPublicMessage(WithTimestamp(New(a, c)), "public"), want: []error{a, c}},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			if got := Causes(tt.err); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Causes(%#v): got: %#v, want %#v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithCaller(t *testing.T) {
+	if WithCaller(nil) != nil {
+		t.Errorf("WithCaller(nil): must return nil")
+	}
+	err := WithCaller(io.EOF)
+	if got, want := err.Error(), "go-xerrors.TestWithCaller: EOF"; got != want {
+		t.Errorf("WithCaller(io.EOF): got: %q, want %q", got, want)
+	}
+	if !errors.Is(err, io.EOF) {
+		t.Errorf("errors.Is(WithCaller(io.EOF), io.EOF): must return true")
+	}
+	if len(StackTrace(err)) != 0 {
+		t.Errorf("WithCaller(io.EOF): returned error must not contain a stack trace")
+	}
+	func() {
+		err = WithCaller(io.EOF)
+	}()
+	if got, want := err.Error(), "go-xerrors.TestWithCaller.func1: EOF"; got != want {
+		t.Errorf("WithCaller(io.EOF): got: %q, want %q", got, want)
+	}
+}
+
+func BenchmarkWrapperError(b *testing.B) {
+	err := Message("foo")
+	for i := 0; i < 50; i++ {
+		err = WithWrapper(Message("bar"), err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = Sprint(err)
+	}
+}
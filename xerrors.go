@@ -1,7 +1,10 @@
 package xerrors
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
+	"unicode/utf8"
 )
 
 // Wrapper provides context around another error.
@@ -30,6 +33,24 @@ type DetailedError interface {
 	ErrorDetails() string
 }
 
+// PanicError is an error created from a value returned by the recover()
+// built-in. The Panic method returns that value, and the StackTrace method
+// returns the stack trace of the panic. The IsRuntimeError method reports
+// whether the value is a runtime.Error, such as a nil pointer dereference,
+// and the PanicType method returns the name of the type of the value.
+// Errors returned by the Recover, RecoverTo and FromRecover functions
+// contain a PanicError that can be obtained using errors.As.
+//
+// If the value is an error, the errors.Is and errors.As functions also
+// match that error.
+type PanicError interface {
+	error
+	Panic() interface{}
+	IsRuntimeError() bool
+	PanicType() string
+	StackTrace() Callers
+}
+
 // messageError is the simplest possible error that contains only
 // a string message.
 type messageError struct {
@@ -38,9 +59,140 @@ type messageError struct {
 
 // Error implements the error interface.
 func (e *messageError) Error() string {
+	return truncateMessage(e.msg)
+}
+
+// fullMessage returns the error message, without truncation.
+func (e *messageError) fullMessage() string {
 	return e.msg
 }
 
+// Is implements the interface used by the errors.Is function. It reports
+// whether target is a messageMatcher with the same message. Other targets
+// are compared by identity, as usual.
+func (e *messageError) Is(target error) bool {
+	m, ok := target.(*messageMatcher)
+	return ok && m.msg == e.msg
+}
+
+// messageMatcher is used by MatchMessage to find message errors with
+// a specific message using the errors.Is function.
+type messageMatcher struct {
+	msg string
+}
+
+// Error implements the error interface.
+func (e *messageMatcher) Error() string {
+	return e.msg
+}
+
+// MatchMessage reports whether err, or any error it wraps, is an error
+// created by the Message function with the same message as target. Unlike
+// errors.Is, which compares sentinel errors by identity, it compares them by
+// their messages.
+//
+// This function is useful when an error crossed a serialization boundary
+// and only its message is known. If target was not created by the Message
+// function, false is returned.
+func MatchMessage(err, target error) bool {
+	t, ok := target.(*messageError)
+	if !ok {
+		return false
+	}
+	return errors.Is(err, &messageMatcher{msg: t.msg})
+}
+
+// IsAny reports whether errors.Is returns true for err and any of the
+// targets. If no targets are given, false is returned.
+func IsAny(err error, targets ...error) bool {
+	for _, target := range targets {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAll reports whether errors.Is returns true for err and all of the
+// targets. If no targets are given, true is returned.
+func IsAll(err error, targets ...error) bool {
+	for _, target := range targets {
+		if !errors.Is(err, target) {
+			return false
+		}
+	}
+	return true
+}
+
+// Equal reports whether a and b are structurally equal errors. Unlike the
+// == operator, it ignores errors that only annotate the error they wrap
+// without changing its message, such as stack traces, timestamps or error
+// codes, so two errors created by the New function with the same values at
+// different places are equal.
+//
+// Errors created by the Message function are compared by their messages,
+// errors created by the WithWrapper function are compared by both the
+// wrapper and the wrapped error, and lists of errors created by the Append
+// function are compared element by element. Other errors are compared using
+// the == operator if their types are comparable.
+func Equal(a, b error) bool {
+	a, b = stripDetails(a), stripDetails(b)
+	if a == nil || b == nil {
+		return a == b
+	}
+	switch ae := a.(type) {
+	case *messageError:
+		be, ok := b.(*messageError)
+		return ok && ae.msg == be.msg
+	case *withWrapper:
+		be, ok := b.(*withWrapper)
+		return ok && Equal(ae.wrapper, be.wrapper) && Equal(ae.err, be.err)
+	case multiError:
+		be, ok := b.(multiError)
+		if !ok || len(ae) != len(be) {
+			return false
+		}
+		for i := range ae {
+			if !Equal(ae[i], be[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	if reflect.TypeOf(a) != reflect.TypeOf(b) || !reflect.TypeOf(a).Comparable() {
+		return false
+	}
+	return a == b
+}
+
+// stripDetails unwraps errors that only add details to the error they wrap.
+func stripDetails(err error) error {
+	for {
+		u := unwrapAnnotation(err)
+		if u == nil {
+			return err
+		}
+		err = u
+	}
+}
+
+// unwrapAnnotation returns the error wrapped by err if err only annotates
+// it, that is, if err implements the Wrapper interface and its message is
+// the same as the message of the wrapped error. Errors that add a stack
+// trace, an error code or a timestamp are such annotations. Otherwise, nil
+// is returned.
+func unwrapAnnotation(err error) error {
+	e, ok := err.(Wrapper)
+	if !ok {
+		return nil
+	}
+	u := e.Unwrap()
+	if u == nil || fullMessage(u) != fullMessage(err) {
+		return nil
+	}
+	return u
+}
+
 // Message creates a simple error with the given message. It does not record
 // a stack trace. Each call returns a distinct error value even if the
 // message is identical.
@@ -51,6 +203,110 @@ func Message(msg string) error {
 	return &messageError{msg: msg}
 }
 
+var maxMessageLength int
+
+// SetMaxMessageLength sets the maximum length, in bytes, of messages of
+// errors created by the Message, New and WithWrapper functions. Longer
+// messages are truncated and followed by "...". It protects logs from
+// errors with huge messages, for example messages that contain a whole
+// request. The full message is included in the error details printed by
+// the Print, Sprint and Fprint functions. If n is 0 or less, messages are
+// not truncated, which is the default.
+//
+// This function is not thread-safe and should be called only once, during
+// the program initialization.
+func SetMaxMessageLength(n int) {
+	maxMessageLength = n
+}
+
+// truncateMessage truncates msg to the length set by SetMaxMessageLength.
+// The message is never cut in the middle of a UTF-8 encoded character.
+func truncateMessage(msg string) string {
+	if maxMessageLength <= 0 || len(msg) <= maxMessageLength {
+		return msg
+	}
+	n := maxMessageLength
+	for n > 0 && !utf8.RuneStart(msg[n]) {
+		n--
+	}
+	return msg[:n] + "..."
+}
+
+// fullMessage returns the message of err, without truncation.
+func fullMessage(err error) string {
+	if e, ok := err.(interface{ fullMessage() string }); ok {
+		return e.fullMessage()
+	}
+	return err.Error()
+}
+
+var skipRedundantStack bool
+
+// SetSkipRedundantStack enables or disables recording of redundant stack
+// traces by the New function. If enabled, New does not record a stack trace
+// if the created error already contains one, for example, when an error
+// returned from a lower layer is wrapped with a message.
+//
+// This function is not thread-safe and should be called only once, during
+// the program initialization.
+func SetSkipRedundantStack(enabled bool) {
+	skipRedundantStack = enabled
+}
+
+var maxWrapDepth int
+
+// SetMaxWrapDepth sets the maximum depth of error chains created by the New
+// and WithWrapper functions, as returned by the Depth function. If wrapping
+// an error would exceed the limit, the error is returned without wrapping.
+// It protects against unbounded chains created, for example, by retry loops
+// that repeatedly wrap the same error. If n is 0 or less, the depth is not
+// limited, which is the default.
+//
+// This function is not thread-safe and should be called only once, during
+// the program initialization.
+func SetMaxWrapDepth(n int) {
+	maxWrapDepth = n
+}
+
+// Depth returns the number of errors that can be obtained from err by
+// repeatedly calling the Unwrap method. If err is nil or it does not wrap
+// any error, 0 is returned.
+func Depth(err error) int {
+	n := 0
+	for err != nil {
+		e, ok := err.(Wrapper)
+		if !ok {
+			break
+		}
+		if err = e.Unwrap(); err != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// RootCause returns the innermost error in the error chain, that is, the
+// last error that can be obtained from err by repeatedly calling the Unwrap
+// method. For errors created by the WithWrapper function, the wrapped error
+// is followed, not the wrapper. Lists of errors created by the Append
+// function do not have a single root cause, so they are returned as is.
+//
+// If err is nil, nil is returned.
+func RootCause(err error) error {
+	for err != nil {
+		e, ok := err.(Wrapper)
+		if !ok {
+			break
+		}
+		u := e.Unwrap()
+		if u == nil {
+			break
+		}
+		err = u
+	}
+	return err
+}
+
 // New creates a new error from the given value and records a stack trace at
 // the point it was called. If multiple values are provided, then each error
 // is wrapped by the previous error. Calling New(a, b, c), where a, b, and c
@@ -70,12 +326,15 @@ func Message(msg string) error {
 //
 // Values are converted to errors according to the following rules:
 //
-// - If a value is an error, it will be used as is.
+// - If a value is an error, it will be used as is, even if it also implements
+// the fmt.Stringer interface. To prefer the String method, use the
+// NewStringer function.
 //
 // - If a value is a string, then new error with a given string as a message
 // will be created.
 //
-// - If a value is nil, it will be ignored.
+// - If a value is nil, it will be ignored. This includes errors with a nil
+// value, such as a nil *MyError pointer stored in an error interface.
 //
 // - If a value implements the fmt.Stringer interface, then a String() method
 // will be used to create an error.
@@ -91,29 +350,124 @@ func Message(msg string) error {
 //
 // To create a simple message error without a stack trace to be used as a
 // sentinel error, use the Message function instead.
+//
+// By default, New always records a stack trace, even if one of the values
+// already contains one. This can be changed using the SetSkipRedundantStack
+// function.
 func New(vals ...interface{}) error {
+	return newError(toError, vals, 1)
+}
+
+// NewSkip works like New, but it skips the given number of stack frames
+// when recording the stack trace. If skip is 0, it works exactly like New.
+//
+// This function is intended for helper functions that create errors on
+// behalf of their callers, so that the stack trace starts at the caller of
+// the helper function instead of the helper function itself.
+func NewSkip(skip int, vals ...interface{}) error {
+	return newError(toError, vals, skip+1)
+}
+
+// NewStringer works like New, but values that implement the fmt.Stringer
+// interface are converted to errors using their String method, even if they
+// also implement the error interface. Such values are converted to message
+// errors, so they cannot be matched using the errors.Is function.
+//
+// Values are converted to errors according to the following rules, in order:
+//
+// - If a value implements the fmt.Stringer interface, then a String() method
+// will be used to create an error.
+//
+// - If a value is an error, it will be used as is.
+//
+// - Other values are converted in the same way as in the New function.
+func NewStringer(vals ...interface{}) error {
+	return newError(toStringerError, vals, 1)
+}
+
+// newError implements the New function. Values are converted to errors using
+// the conv function, and the stack trace is recorded after skipping skip
+// frames, as in the WithStackTrace function.
+func newError(conv func(interface{}) error, vals []interface{}, skip int) error {
+	err := join(conv, vals)
+	if !needsStackTrace(err) {
+		return err
+	}
+	return newWithStackTrace(err, skip+1)
+}
+
+// join converts values to errors using the conv function, and wraps each
+// error by the previous one using the WithWrapper function, so the depth
+// limit set by SetMaxWrapDepth applies. Nil values, including nil pointers
+// stored in an error interface, are ignored. If there are no values to
+// convert, nil is returned.
+func join(conv func(interface{}) error, vals []interface{}) error {
 	var errs error
 	for _, val := range vals {
-		if val == nil {
+		if val == nil || isNilError(val) {
 			continue
 		}
-		err := toError(val)
+		err := conv(val)
 		if errs == nil {
 			errs = err
 		} else {
-			errs = &withWrapper{
-				wrapper: errs,
-				err:     err,
-			}
+			errs = WithWrapper(errs, err)
 		}
 	}
-	if errs == nil {
-		return nil
+	return errs
+}
+
+// needsStackTrace reports whether the New function should record a stack
+// trace for err.
+func needsStackTrace(err error) bool {
+	if err == nil {
+		return false
+	}
+	if skipRedundantStack && len(StackTrace(err)) > 0 {
+		return false
+	}
+	if maxWrapDepth > 0 && Depth(err) >= maxWrapDepth {
+		return false
+	}
+	return true
+}
+
+// Errorf formats an error message according to a format specifier and
+// records a stack trace at the point it was called. Apart from the stack
+// trace, it works exactly like fmt.Errorf, including the support for the %w
+// verb. The error created by fmt.Errorf can be obtained using the Unwrap
+// method of the returned error.
+func Errorf(format string, args ...interface{}) error {
+	return newWithStackTrace(fmt.Errorf(format, args...), 1)
+}
+
+// ErrorfSkip works like Errorf, but it skips the given number of stack
+// frames when recording the stack trace. If skip is 0, it works exactly like
+// Errorf.
+func ErrorfSkip(skip int, format string, args ...interface{}) error {
+	return newWithStackTrace(fmt.Errorf(format, args...), skip+1)
+}
+
+// isNilError reports whether val is an error with a nil value, such as
+// a nil pointer to a type that implements the error interface. Calling the
+// Error method on such values usually panics.
+func isNilError(val interface{}) bool {
+	if _, ok := val.(error); !ok {
+		return false
 	}
-	return &withStackTrace{
-		err:   errs,
-		stack: callers(1),
+	v := reflect.ValueOf(val)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+func toStringerError(val interface{}) error {
+	if s, ok := val.(fmt.Stringer); ok {
+		return &messageError{msg: s.String()}
 	}
+	return toError(val)
 }
 
 func toError(val interface{}) error {
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -13,6 +15,22 @@ func (s stringer) String() string {
 	return s.s
 }
 
+type ptrErr struct{ msg string }
+
+func (e *ptrErr) Error() string {
+	return e.msg
+}
+
+type stringerErr struct{ err, s string }
+
+func (e stringerErr) Error() string {
+	return e.err
+}
+
+func (e stringerErr) String() string {
+	return e.s
+}
+
 func TestMessage(t *testing.T) {
 	tests := []struct {
 		val  string
@@ -51,6 +69,8 @@ func TestNew(t *testing.T) {
 		{vals: []interface{}{}, wantNil: true},
 		{vals: []interface{}{nil}, wantNil: true},
 		{vals: []interface{}{nil, nil}, wantNil: true},
+		{vals: []interface{}{(*ptrErr)(nil)}, wantNil: true},
+		{vals: []interface{}{"foo", (*ptrErr)(nil), &ptrErr{msg: "bar"}}, want: "foo: bar"},
 	}
 	for n, tt := range tests {
 		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
@@ -68,7 +88,7 @@ func TestNew(t *testing.T) {
 					t.Errorf("New(%#v): returned error must contain a stack trace", tt.vals)
 				}
 				for _, v := range tt.vals {
-					if err, ok := v.(error); ok {
+					if err, ok := v.(error); ok && !isNilError(err) {
 						if !errors.Is(got, err) {
 							t.Errorf("errors.Is(New(errs...), err): must return true")
 						}
@@ -78,3 +98,347 @@ func TestNew(t *testing.T) {
 		})
 	}
 }
+
+func TestNewStringer(t *testing.T) {
+	tests := []struct {
+		vals    []interface{}
+		want    string
+		wantNil bool
+	}{
+		{vals: []interface{}{"foo", "bar"}, want: "foo: bar"},
+		{vals: []interface{}{stringerErr{err: "err", s: "foo"}}, want: "foo"},
+		{vals: []interface{}{stringer{s: "foo"}, io.EOF}, want: "foo: EOF"},
+		{vals: []interface{}{42, nil}, want: "42"},
+		{vals: []interface{}{nil}, wantNil: true},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			got := NewStringer(tt.vals...)
+			switch {
+			case tt.wantNil:
+				if got != nil {
+					t.Errorf("NewStringer(%#v): expected nil", tt.vals)
+				}
+			default:
+				if got.Error() != tt.want {
+					t.Errorf("NewStringer(%#v): got: %q, want %q", tt.vals, got, tt.want)
+				}
+				if frame, _ := Origin(got); frame.Function != "github.com/mdobak/go-xerrors.TestNewStringer.func1" {
+					t.Errorf("NewStringer(%#v): stack trace must start at the caller, got: %q", tt.vals, frame.Function)
+				}
+			}
+		})
+	}
+	if got := New(stringerErr{err: "err", s: "foo"}).Error(); got != "err" {
+		t.Errorf("New(stringerErr): got: %q, want %q", got, "err")
+	}
+}
+
+func TestNewSkip(t *testing.T) {
+	newErr := func(skip int) error { return NewSkip(skip, "foo") }
+	errorf := func(skip int) error { return ErrorfSkip(skip, "foo: %w", io.EOF) }
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{err: newErr(0), want: "github.com/mdobak/go-xerrors.TestNewSkip.func1"},
+		{err: newErr(1), want: "github.com/mdobak/go-xerrors.TestNewSkip"},
+		{err: errorf(0), want: "github.com/mdobak/go-xerrors.TestNewSkip.func2"},
+		{err: errorf(1), want: "github.com/mdobak/go-xerrors.TestNewSkip"},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			if frame, _ := Origin(tt.err); frame.Function != tt.want {
+				t.Errorf("Origin(err): got: %q, want: %q", frame.Function, tt.want)
+			}
+		})
+	}
+	if err := NewSkip(1, nil); err != nil {
+		t.Errorf("NewSkip(1, nil): expected nil")
+	}
+	if err := ErrorfSkip(0, "foo: %w", io.EOF); !errors.Is(err, io.EOF) {
+		t.Errorf("errors.Is(ErrorfSkip(0, \"foo: %%w\", io.EOF), io.EOF): must return true")
+	}
+}
+
+func BenchmarkNew(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = New("foo")
+	}
+}
+
+func BenchmarkNewFrames(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = StackTrace(New("foo")).Frames()
+	}
+}
+
+func TestMatchMessage(t *testing.T) {
+	tests := []struct {
+		err    error
+		target error
+		want   bool
+	}{
+		{err: Message("foo"), target: Message("foo"), want: true},
+		{err: New("foo"), target: Message("foo"), want: true},
+		{err: New("bar", Message("foo")), target: Message("foo"), want: true},
+		{err: WithWrapper(Message("foo"), io.EOF), target: Message("foo"), want: true},
+		{err: Append(Message("bar"), Message("foo")), target: Message("foo"), want: true},
+		{err: Message("foo"), target: Message("bar"), want: false},
+		{err: io.EOF, target: Message("EOF"), want: false},
+		{err: Message("foo"), target: New("foo"), want: false},
+		{err: nil, target: Message("foo"), want: false},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			if got := MatchMessage(tt.err, tt.target); got != tt.want {
+				t.Errorf("MatchMessage(%#v, %#v): got: %v, want %v", tt.err, tt.target, got, tt.want)
+			}
+		})
+	}
+	if errors.Is(Message("foo"), Message("foo")) {
+		t.Errorf("errors.Is(Message(\"foo\"), Message(\"foo\")): must return false for distinct errors")
+	}
+}
+
+func TestSetSkipRedundantStack(t *testing.T) {
+	defer SetSkipRedundantStack(false)
+
+	inner := New("foo")
+	if err := New("bar", inner); StackTrace(err).String() == StackTrace(inner).String() {
+		t.Errorf("New(\"bar\", err): must record a new stack trace by default")
+	}
+	SetSkipRedundantStack(true)
+	err := New("bar", inner)
+	if got := err.Error(); got != "bar: foo" {
+		t.Errorf("New(\"bar\", err): got: %q, want %q", got, "bar: foo")
+	}
+	if StackTrace(err).String() != StackTrace(inner).String() {
+		t.Errorf("New(\"bar\", err): must not record a new stack trace")
+	}
+	if len(StackTrace(New("bar", io.EOF))) == 0 {
+		t.Errorf("New(\"bar\", io.EOF): must record a stack trace if none is present")
+	}
+}
+
+func TestIsAnyIsAll(t *testing.T) {
+	a, b, c := Message("a"), Message("b"), Message("c")
+	tests := []struct {
+		err     error
+		targets []error
+		wantAny bool
+		wantAll bool
+	}{
+		{err: a, targets: nil, wantAny: false, wantAll: true},
+		{err: a, targets: []error{a}, wantAny: true, wantAll: true},
+		{err: a, targets: []error{b, c}, wantAny: false, wantAll: false},
+		{err: New(a), targets: []error{b, a}, wantAny: true, wantAll: false},
+		{err: WithWrapper(a, b), targets: []error{a, b}, wantAny: true, wantAll: true},
+		{err: Append(a, b), targets: []error{c, b}, wantAny: true, wantAll: false},
+		{err: Append(a, b), targets: []error{a, b}, wantAny: true, wantAll: true},
+		{err: nil, targets: []error{a}, wantAny: false, wantAll: false},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			if got := IsAny(tt.err, tt.targets...); got != tt.wantAny {
+				t.Errorf("IsAny(%#v, %#v): got: %v, want %v", tt.err, tt.targets, got, tt.wantAny)
+			}
+			if got := IsAll(tt.err, tt.targets...); got != tt.wantAll {
+				t.Errorf("IsAll(%#v, %#v): got: %v, want %v", tt.err, tt.targets, got, tt.wantAll)
+			}
+		})
+	}
+}
+
+func TestEqual(t *testing.T) {
+	a, b := Message("a"), Message("b")
+	tests := []struct {
+		a, b error
+		want bool
+	}{
+		{a: nil, b: nil, want: true},
+		{a: a, b: nil, want: false},
+		{a: a, b: a, want: true},
+		{a: a, b: Message("a"), want: true},
+		{a: a, b: b, want: false},
+		{a: New(a), b: a, want: true},
+		{a: New("a"), b: WithTimestamp(New("a")), want: true},
+		{a: New("a", b), b: WithCode(WithHTTPStatus(New("a", b), 404), 1), want: true},
+		{a: New("a", b), b: WithSeverity(New("a", WithRetryable(b)), SeverityWarn), want: true},
+		{a: New("a", b), b: New("a", New(b)), want: true},
+		{a: New("a", b), b: New("b", a), want: false},
+		{a: Append(a, b), b: Append(New(a), b), want: true},
+		{a: Append(a, b), b: Append(b, a), want: false},
+		{a: Append(a, b), b: a, want: false},
+		{a: io.EOF, b: io.EOF, want: true},
+		{a: io.EOF, b: io.ErrUnexpectedEOF, want: false},
+		{a: New(io.EOF), b: New(io.EOF), want: true},
+		{a: fmt.Errorf("a"), b: fmt.Errorf("a"), want: false},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			if got := Equal(tt.a, tt.b); got != tt.want {
+				t.Errorf("Equal(%#v, %#v): got: %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+			if got := Equal(tt.b, tt.a); got != tt.want {
+				t.Errorf("Equal(%#v, %#v): got: %v, want %v", tt.b, tt.a, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorf(t *testing.T) {
+	tests := []struct {
+		format string
+		args   []interface{}
+		want   string
+		is     []error
+	}{
+		{format: "foo", want: "foo"},
+		{format: "foo: %d", args: []interface{}{42}, want: "foo: 42"},
+		{format: "foo: %w", args: []interface{}{io.EOF}, want: "foo: EOF", is: []error{io.EOF}},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			got := Errorf(tt.format, tt.args...)
+			if got.Error() != tt.want {
+				t.Errorf("Errorf(%q, %#v): got: %q, want %q", tt.format, tt.args, got, tt.want)
+			}
+			st := StackTrace(got)
+			if len(st) == 0 || shortname(st.Frames()[0].Function) != "go-xerrors.TestErrorf.func1" {
+				t.Errorf("Errorf(%q, %#v): the first frame of stack trace must start at xerrors.TestErrorf.func1", tt.format, tt.args)
+			}
+			for _, err := range tt.is {
+				if !errors.Is(got, err) {
+					t.Errorf("errors.Is(Errorf(%q, %#v), err): must return true", tt.format, tt.args)
+				}
+			}
+		})
+	}
+}
+
+func TestDepth(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{err: nil, want: 0},
+		{err: io.EOF, want: 0},
+		{err: New(io.EOF), want: 1},
+		{err: New("foo", io.EOF), want: 2},
+		{err: New(New(io.EOF)), want: 2},
+		{err: fmt.Errorf("foo: %w", io.EOF), want: 1},
+		{err: fmt.Errorf("foo: %v", io.EOF), want: 0},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			if got := Depth(tt.err); got != tt.want {
+				t.Errorf("Depth(%#v): got: %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetMaxWrapDepth(t *testing.T) {
+	defer SetMaxWrapDepth(0)
+
+	SetMaxWrapDepth(3)
+	err := error(Message("foo"))
+	for i := 0; i < 10; i++ {
+		err = New(err)
+	}
+	if got := Depth(err); got != 3 {
+		t.Errorf("Depth(New(...New(err))): got: %d, want 3", got)
+	}
+	err = Message("foo")
+	for i := 0; i < 10; i++ {
+		err = New("retry", err)
+	}
+	if got := Depth(err); got != 3 {
+		t.Errorf("Depth(New(\"retry\", ...New(\"retry\", err))): got: %d, want 3", got)
+	}
+	err = Message("foo")
+	for i := 0; i < 10; i++ {
+		err = WithWrapper(Message("bar"), err)
+	}
+	if got := Depth(err); got != 3 {
+		t.Errorf("Depth(WithWrapper(...WithWrapper(err))): got: %d, want 3", got)
+	}
+	SetMaxWrapDepth(0)
+	if got := Depth(New(New(New(New(io.EOF))))); got != 4 {
+		t.Errorf("Depth(New(New(New(New(err))))): got: %d, want 4", got)
+	}
+}
+
+func TestRootCause(t *testing.T) {
+	multi := Append(Message("a"), Message("b"))
+	tests := []struct {
+		err  error
+		want error
+	}{
+		{err: nil, want: nil},
+		{err: io.EOF, want: io.EOF},
+		{err: New(io.EOF), want: io.EOF},
+		{err: New("foo", New("bar", io.EOF)), want: io.EOF},
+		{err: WithWrapper(io.ErrUnexpectedEOF, io.EOF), want: io.EOF},
+		{err: fmt.Errorf("foo: %w", io.EOF), want: io.EOF},
+		{err: New(multi), want: multi},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			if got := RootCause(tt.err); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RootCause(%#v): got: %#v, want %#v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetMaxMessageLength(t *testing.T) {
+	defer SetMaxMessageLength(0)
+
+	SetMaxMessageLength(5)
+	tests := []struct {
+		err  error
+		want string
+		full string
+	}{
+		{err: Message("foo"), want: "foo", full: "foo"},
+		{err: Message("foobar"), want: "fooba...", full: "foobar"},
+		{err: Message("zażółć"), want: "zaż...", full: "zażółć"},
+		{err: New("foo", "bar"), want: "foo: ...", full: "foo: bar"},
+		{err: New("a", WithWrapper(Message("bar"), Message("baz"))), want: "a: ba...", full: "a: bar: baz"},
+		{err: io.EOF, want: "EOF", full: "EOF"},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("Error(): got: %q, want: %q", got, tt.want)
+			}
+			if got := fullMessage(tt.err); got != tt.full {
+				t.Errorf("fullMessage(err): got: %q, want: %q", got, tt.full)
+			}
+			got := SprintWithoutStack(tt.err)
+			want := "Error: " + tt.want + "\n"
+			if tt.full != tt.want {
+				want += "\tfull message: " + tt.full + "\n"
+			}
+			if got != want {
+				t.Errorf("SprintWithoutStack(err): got: %q, want: %q", got, want)
+			}
+		})
+	}
+	err := New("hello world", New("hello there"))
+	if got := Sprint(err); !strings.Contains(got, "Previous error: hello...\n\tfull message: hello there\n") {
+		t.Errorf("Sprint(err): %q must not combine errors with the same truncated message", got)
+	}
+	if got, want := SprintTree(err), "hello...\n└─ hello...\n"; got != want {
+		t.Errorf("SprintTree(err): got: %q, want: %q", got, want)
+	}
+	r := &Registry{}
+	r.Register(Message("hello world"))
+	if _, ok := r.Resolve("hello world"); !ok {
+		t.Errorf("Registry.Resolve(\"hello world\"): errors must be registered under their full messages")
+	}
+}
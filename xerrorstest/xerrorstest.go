@@ -0,0 +1,45 @@
+// Package xerrorstest provides helper functions for testing errors. It is
+// a separate package, so that the xerrors package does not depend on the
+// testing package.
+package xerrorstest
+
+import (
+	"errors"
+	"strconv"
+)
+
+// TB is the subset of the testing.TB interface used by this package.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// AssertIs reports a test failure if errors.Is(err, target) returns false.
+// It returns the result of errors.Is.
+func AssertIs(tb TB, err, target error) bool {
+	tb.Helper()
+	if errors.Is(err, target) {
+		return true
+	}
+	tb.Errorf("error does not match the target:\n\terror:  %s\n\ttarget: %s", message(err), message(target))
+	return false
+}
+
+// AssertMessage reports a test failure if err is nil or its message is
+// different from msg. It returns true if the messages are equal.
+func AssertMessage(tb TB, err error, msg string) bool {
+	tb.Helper()
+	if err != nil && err.Error() == msg {
+		return true
+	}
+	tb.Errorf("unexpected error message:\n\tgot:  %s\n\twant: %q", message(err), msg)
+	return false
+}
+
+// message returns the quoted message of err, or "<nil>" if err is nil.
+func message(err error) string {
+	if err == nil {
+		return "<nil>"
+	}
+	return strconv.Quote(err.Error())
+}
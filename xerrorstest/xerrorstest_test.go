@@ -0,0 +1,81 @@
+package xerrorstest
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/mdobak/go-xerrors"
+)
+
+type testTB struct {
+	helper bool
+	errors []string
+}
+
+func (tb *testTB) Helper() {
+	tb.helper = true
+}
+
+func (tb *testTB) Errorf(format string, args ...interface{}) {
+	tb.errors = append(tb.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertIs(t *testing.T) {
+	tests := []struct {
+		err    error
+		target error
+		want   string
+	}{
+		{err: io.EOF, target: io.EOF},
+		{err: xerrors.New("foo", io.EOF), target: io.EOF},
+		{err: xerrors.New("foo"), target: io.EOF, want: "error does not match the target:\n\terror:  \"foo\"\n\ttarget: \"EOF\""},
+		{err: nil, target: io.EOF, want: "error does not match the target:\n\terror:  <nil>\n\ttarget: \"EOF\""},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			tb := &testTB{}
+			ok := AssertIs(tb, tt.err, tt.target)
+			if !tb.helper {
+				t.Errorf("AssertIs(tb, err, target): must call tb.Helper")
+			}
+			if ok != (tt.want == "") {
+				t.Errorf("AssertIs(tb, err, target): got: %v, want: %v", ok, tt.want == "")
+			}
+			if got := strings.Join(tb.errors, "\n"); got != tt.want {
+				t.Errorf("AssertIs(tb, err, target): got: %q, want: %q", got, tt.want)
+			}
+		})
+	}
+	AssertIs(t, xerrors.New(io.EOF), io.EOF)
+}
+
+func TestAssertMessage(t *testing.T) {
+	tests := []struct {
+		err  error
+		msg  string
+		want string
+	}{
+		{err: io.EOF, msg: "EOF"},
+		{err: xerrors.New("foo", io.EOF), msg: "foo: EOF"},
+		{err: xerrors.New("foo"), msg: "bar", want: "unexpected error message:\n\tgot:  \"foo\"\n\twant: \"bar\""},
+		{err: nil, msg: "", want: "unexpected error message:\n\tgot:  <nil>\n\twant: \"\""},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			tb := &testTB{}
+			ok := AssertMessage(tb, tt.err, tt.msg)
+			if !tb.helper {
+				t.Errorf("AssertMessage(tb, err, msg): must call tb.Helper")
+			}
+			if ok != (tt.want == "") {
+				t.Errorf("AssertMessage(tb, err, msg): got: %v, want: %v", ok, tt.want == "")
+			}
+			if got := strings.Join(tb.errors, "\n"); got != tt.want {
+				t.Errorf("AssertMessage(tb, err, msg): got: %q, want: %q", got, tt.want)
+			}
+		})
+	}
+	AssertMessage(t, xerrors.New("foo"), "foo")
+}